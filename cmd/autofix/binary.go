@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/autofix/cli/internal/fixengine"
+)
+
+func runInstallBinary(args []string) int {
+	if len(args) != 1 {
+		fmt.Println("Usage: autofix install-binary <name>")
+		return 1
+	}
+
+	path, err := fixengine.FetchBinary(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Installed %s to %s\n", args[0], path)
+	return 0
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+)
+
+func loadCapturedLog(path string, command string) (*executor.Result, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &executor.Result{
+		Command:  command,
+		ExitCode: 1,
+		Stderr:   string(content),
+		Success:  false,
+	}, nil
+}
+
+func printDryRun(plan *fixengine.DryRun) {
+	fmt.Fprintln(stdout, "[Dry Run]")
+	fmt.Fprintf(stdout, "Exit Code: %d\n", plan.Result.ExitCode)
+
+	if plan.Result.Success {
+		fmt.Fprintln(stdout, "Command succeeded, nothing to fix")
+		return
+	}
+
+	fmt.Fprintf(stdout, "Error Type: %s\n", plan.ErrorInfo.Type)
+	fmt.Fprintf(stdout, "Message: %s\n", plan.ErrorInfo.Message)
+	printPlan(plan)
+}
+
+func printPlan(plan *fixengine.DryRun) {
+	if plan.Plan == nil {
+		fmt.Fprintln(stdout, "Plan: no fix available")
+		return
+	}
+
+	fix := plan.Plan
+	fmt.Fprintln(stdout, "[Plan]")
+	fmt.Fprintf(stdout, "Source: %s\n", fix.Source)
+	if fix.Explanation != "" {
+		fmt.Fprintf(stdout, "Explanation: %s\n", fix.Explanation)
+	}
+	for i, step := range fix.Steps {
+		fmt.Fprintf(stdout, "%d. %s\n", i+1, step)
+		if step.Precondition != "" {
+			fmt.Fprintf(stdout, "   precondition: %s\n", step.Precondition)
+		}
+		if step.Rollback != "" {
+			fmt.Fprintf(stdout, "   rollback: %s\n", step.Rollback)
+		}
+	}
+	if fix.Type == fixengine.FixTypeReplacement {
+		fmt.Fprintln(stdout, "   (replaces the original command)")
+	} else if plan.Command != "" {
+		fmt.Fprintf(stdout, "%d. Retry: %s\n", len(fix.Steps)+1, plan.Command)
+	}
+
+	fmt.Fprintf(stdout, "Fix Type: %s\n", fix.Type)
+	fmt.Fprintf(stdout, "Risk Level: %s\n", fix.RiskLevel)
+	fmt.Fprintf(stdout, "Requires Sudo: %v\n", plan.RequiresSudo)
+	fmt.Fprintf(stdout, "Needs Confirmation: %v\n", plan.NeedsConfirm)
+	if plan.SafetyError != "" {
+		fmt.Fprintf(stdout, "Safety Check: %s\n", plan.SafetyError)
+	} else {
+		fmt.Fprintln(stdout, "Safety Check: passed")
+	}
+}
+
+func runDryRun(fixEngine *fixengine.FixEngine, opts *runOptions) int {
+	var captured *executor.Result
+	if opts.Log != "" {
+		var err error
+		captured, err = loadCapturedLog(opts.Log, opts.Command)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error: failed to read log: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "[Analyzing Log] %s\n", opts.Log)
+	} else {
+		fmt.Fprintln(stdout, "[Executing Command]")
+		fmt.Fprintf(stdout, "Command: %s\n", opts.Command)
+	}
+
+	plan, err := fixEngine.DryRun(opts.Command, captured)
+	if err != nil {
+		fmt.Fprintf(stdout, "[Error] %v\n", err)
+		return 1
+	}
+
+	if opts.Output != outputText {
+		if opts.Output == outputNDJSON {
+			json.NewEncoder(os.Stdout).Encode(plan)
+		} else {
+			writeJSON(plan)
+		}
+		return 0
+	}
+	printDryRun(plan)
+	return 0
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/autofix/cli/internal/ensure"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/journal"
+	"github.com/autofix/cli/internal/prompt"
+	"github.com/autofix/cli/internal/workflow"
+)
+
+func runEnsure(args []string) int {
+	fs := flag.NewFlagSet("ensure", flag.ExitOnError)
+	check := fs.Bool("check", false, "only report missing or outdated requirements; exit 1 if there are any")
+	file := fs.String("file", "", "workflow file listing requirements (default: autofix.yaml in this directory or a parent)")
+	yes := fs.Bool("yes", false, "answer yes to every confirmation")
+	noInput := fs.Bool("no-input", false, "never prompt; decline anything that needs confirmation")
+	output := fs.String("output", outputText, "with --check, output format: text or json")
+	fs.Parse(args)
+
+	if *output != outputText && *output != outputJSON {
+		fmt.Printf("Error: unknown output format %q (want text or json)\n", *output)
+		return 2
+	}
+	asJSON := *output == outputJSON
+
+	var requirements []*ensure.Requirement
+	for _, spec := range fs.Args() {
+		r, err := ensure.Parse(spec)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 2
+		}
+		requirements = append(requirements, r)
+	}
+	if len(requirements) == 0 {
+		path := *file
+		var err error
+		if path == "" {
+			if path, err = workflow.Find("."); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return 2
+			}
+		}
+		w, err := workflow.Load(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		if len(w.Requires) == 0 {
+			fmt.Printf("Error: %s has no requires list\n", path)
+			return 2
+		}
+		requirements = w.Requires
+	}
+
+	if asJSON {
+		stdout = os.Stderr
+	}
+	environment := env.Detect()
+	local := executor.NewLocal()
+	if *check {
+		statuses := checkRequirements(local, environment, requirements)
+		if asJSON {
+			writeJSON(statuses)
+		}
+		for _, s := range statuses {
+			if !s.OK {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	fixEngine := newStepEngine(environment, local, *yes, *noInput)
+	fixEngine.Events = events.NewBus(events.NewConsole(stdout))
+	return ensureRequirements(fixEngine, requirements)
+}
+
+func checkRequirements(ex executor.Executor, environment *env.Environment, requirements []*ensure.Requirement) []*ensure.Status {
+	statuses := []*ensure.Status{}
+	for _, r := range requirements {
+		s := ensure.Check(ex, environment, r)
+		fmt.Fprintf(stdout, "[Ensure] %-20s %s\n", r, s)
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// ensureRequirements installs or upgrades every unmet requirement and
+// checks it again afterwards. Installs are journaled as one run.
+func ensureRequirements(fixEngine *fixengine.FixEngine, requirements []*ensure.Requirement) int {
+	statuses := checkRequirements(fixEngine.Executor, fixEngine.Environment, requirements)
+	var unmet []*ensure.Status
+	for _, s := range statuses {
+		if !s.OK {
+			unmet = append(unmet, s)
+		}
+	}
+	if len(unmet) == 0 {
+		return 0
+	}
+
+	j, err := journal.New(journal.NewRunID(), "autofix ensure", runDir(""), "")
+	if err != nil {
+		fmt.Fprintf(stdout, "[Error] journal: %v\n", err)
+		return fixengine.OutcomeError.ExitCode()
+	}
+	fixEngine.Journal = j
+	defer func() {
+		if len(j.Entries) > 0 {
+			fmt.Fprintf(stdout, "[Journal] run %s recorded; undo with: autofix undo %s\n", j.RunID, j.RunID)
+		}
+	}()
+
+	code := 0
+	for _, s := range unmet {
+		plan, err := fixEngine.EnsurePlan(s)
+		if err != nil {
+			fmt.Fprintf(stdout, "[Error] %s: %v\n", s.Requirement, err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "[Ensure] %s: %s\n", s.Requirement, plan.Explanation)
+		if _, err := fixEngine.Apply(plan); err != nil {
+			fmt.Fprintf(stdout, "[Error] %s: %v\n", s.Requirement, err)
+			if outcome := fixengine.OutcomeOf(nil, err, false); outcome == fixengine.OutcomeDeclined || errors.Is(err, prompt.ErrNoInput) {
+				return outcome.ExitCode()
+			}
+			code = 1
+			continue
+		}
+
+		after := ensure.Check(fixEngine.Executor, env.Detect(), s.Requirement)
+		switch {
+		case after.OK:
+			fmt.Fprintf(stdout, "[Ensure] %-20s %s\n", s.Requirement, after)
+		case ensure.Strategy(s.Requirement) != ensure.ViaPackage:
+			fmt.Fprintf(stdout, "[Ensure] %s installed with %s; start a new shell to pick it up\n", s.Requirement, ensure.Strategy(s.Requirement))
+		default:
+			fmt.Fprintf(stdout, "[Ensure] %-20s still %s\n", s.Requirement, after)
+			code = 1
+		}
+	}
+	return code
+}
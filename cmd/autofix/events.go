@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/events"
+)
+
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// newEventBus renders engine events as human-readable text and, when
+// asked, also as NDJSON or a JSON report on stdout, an NDJSON file and a
+// structured log file. The returned function writes the JSON report and
+// closes any files that were opened.
+func newEventBus(opts *runOptions) (*events.Bus, func(), error) {
+	bus := events.NewBus(events.NewConsole(stdout))
+	files := []*os.File{}
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	open := func(path string, flags int) (io.Writer, error) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(path, flags|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		return f, nil
+	}
+
+	if opts.Events != "" {
+		w, err := open(opts.Events, os.O_TRUNC)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to open event stream: %w", err)
+		}
+		bus.Subscribe(events.NewNDJSON(w))
+	}
+
+	logPath := opts.LogFile
+	if logPath == "" {
+		logPath = config.Get().Log.File
+	}
+	if logPath != "" {
+		w, err := open(logPath, os.O_APPEND)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		bus.Subscribe(events.NewLog(w))
+	}
+
+	switch opts.Output {
+	case outputNDJSON:
+		bus.Subscribe(events.NewNDJSON(os.Stdout))
+	case outputJSON:
+		// A dry run prints its plan as the one JSON document instead.
+		if opts.DryRun {
+			break
+		}
+		report := events.NewReport()
+		bus.Subscribe(report)
+		return bus, func() {
+			writeJSON(report)
+			closeAll()
+		}, nil
+	}
+	return bus, closeAll, nil
+}
+
+func writeJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/kb"
+	"github.com/autofix/cli/internal/llm"
+)
+
+// inspectOnly stands in for the executor during explain: local
+// strategies may still inspect files and PATH, but no command runs.
+type inspectOnly struct {
+	local bool
+}
+
+func (i inspectOnly) Run(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+	return &executor.Result{Command: req.Command, ExitCode: 127, Stderr: "not executed by autofix explain\n"}, nil
+}
+
+func (i inspectOnly) Unwrap() executor.Executor {
+	if i.local {
+		return executor.NewLocal()
+	}
+	return nil
+}
+
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	command := fs.String("command", "", "the command that produced the log")
+	exitCode := fs.Int("exit-code", 1, "the command's exit code")
+	noLLM := fs.Bool("no-llm", false, "use the deterministic rules only")
+	output := fs.String("output", outputText, "output format: text or json")
+	osName := fs.String("os", "", "explain for this OS instead of the local machine (e.g. debian, fedora, macos)")
+	osVersion := fs.String("os-version", "", "target OS version")
+	pm := fs.String("package-manager", "", "target package manager (apt, dnf, yum, pacman, brew)")
+	arch := fs.String("arch", "", "target architecture (amd64, arm64)")
+	sudo := fs.Bool("sudo", false, "the target environment has sudo")
+	root := fs.Bool("root", false, "the target environment runs as root")
+	fs.Parse(args)
+
+	if *output != outputText && *output != outputJSON {
+		fmt.Printf("Error: unknown output format %q (want text or json)\n", *output)
+		return 2
+	}
+	if *output == outputJSON {
+		stdout = os.Stderr
+	}
+
+	var in io.Reader = os.Stdin
+	source := "stdin"
+	if path := fs.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		in, source = f, path
+	}
+	log, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error: failed to read log: %v\n", err)
+		return 1
+	}
+
+	// Any target setting means the log came from another machine, so
+	// nothing on this one may be inspected.
+	environment := env.Detect()
+	target := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "os", "os-version", "package-manager", "arch", "sudo", "root":
+			target = true
+		}
+	})
+	if target {
+		environment = &env.Environment{
+			OS:             env.OS(*osName),
+			OSVersion:      *osVersion,
+			Architecture:   environment.Architecture,
+			PackageManager: env.PackageManager(*pm),
+			HasSudo:        *sudo,
+			IsRoot:         *root,
+		}
+		if *osName == "" {
+			environment.OS = env.OSUnknown
+		}
+		if *arch != "" {
+			environment.Architecture = env.Architecture(*arch)
+		}
+		if *pm == "" {
+			environment.PackageManager = env.DefaultPackageManager(environment.OS)
+		}
+	}
+
+	cfg := config.Get()
+	var llmClient llm.Client
+	if !*noLLM {
+		llmClient = llm.NewClient(cfg.LLM.Provider, cfg.LLM.APIKey, cfg.LLM.Endpoint, cfg.LLM.Model)
+	}
+	fixEngine := fixengine.New(environment, llmClient, inspectOnly{local: !target})
+	fixEngine.Events = events.NewBus(events.NewConsole(stdout))
+	fixEngine.Recipes = loadRecipes()
+	if cfg.KB.Enabled {
+		if dir, err := kb.DefaultDir(); err == nil {
+			fixEngine.KB = kb.Open(dir)
+		}
+	}
+
+	fmt.Fprintf(stdout, "[Explaining] %s\n", source)
+	captured := &executor.Result{Command: *command, ExitCode: *exitCode, Stderr: string(log), Success: *exitCode == 0}
+	explanation, err := fixEngine.Explain(*command, captured)
+	if err != nil {
+		fmt.Fprintf(stdout, "[Error] %v\n", err)
+		return 1
+	}
+
+	code := 0
+	if explanation.Plan == nil {
+		code = fixengine.OutcomeNoFix.ExitCode()
+	}
+	if *output == outputJSON {
+		writeJSON(explanation)
+		return code
+	}
+	printExplanation(explanation, environment, target)
+	return code
+}
+
+func printExplanation(e *fixengine.Explanation, environment *env.Environment, target bool) {
+	if e.Result.Success {
+		fmt.Fprintln(stdout, "Exit code 0: nothing to explain")
+		return
+	}
+	if target {
+		fmt.Fprintf(stdout, "Environment: %s, %s, %s\n", strings.TrimSpace(string(environment.OS)+" "+environment.OSVersion), environment.Architecture, environment.PackageManager)
+	}
+	fmt.Fprintf(stdout, "[Classification] %s: %s (%s)\n", e.ErrorInfo.Type, e.ErrorInfo.Message, e.Fingerprint)
+	if len(e.Evidence) > 0 {
+		fmt.Fprintln(stdout, "[Evidence]")
+		for _, line := range e.Evidence {
+			fmt.Fprintf(stdout, "  %s\n", line)
+		}
+	}
+	fmt.Fprintf(stdout, "[Likely Cause] %s\n", e.Cause)
+	printPlan(&e.DryRun)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/history"
+	"github.com/autofix/cli/internal/journal"
+)
+
+func historyStore() (*history.Store, error) {
+	dir, err := history.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.Get()
+	store := history.Open(dir)
+	store.MaxAge = time.Duration(cfg.History.MaxAgeDays) * 24 * time.Hour
+	store.MaxSize = int64(cfg.History.MaxSizeMB) << 20
+	return store, nil
+}
+
+func saveHistory(recorder *history.Recorder) {
+	store, err := historyStore()
+	if err == nil {
+		err = store.Save(recorder.Run())
+	}
+	if err != nil {
+		fmt.Fprintf(stdout, "[History] failed to save run: %v\n", err)
+	}
+}
+
+func runDir(dir string) string {
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// parseTime accepts a date, a timestamp or a duration ago such as 24h.
+// With end set, a date or a time to the minute stands for its last
+// moment, so that --until 2026-10-01 takes in that whole day.
+func parseTime(value string, end bool) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	layouts := []struct {
+		layout string
+		next   func(time.Time) time.Time
+	}{
+		{time.RFC3339, nil},
+		{"2006-01-02 15:04", func(t time.Time) time.Time { return t.Add(time.Minute) }},
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+	}
+	for _, l := range layouts {
+		t, err := time.ParseInLocation(l.layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		if end && l.next != nil {
+			t = l.next(t).Add(-time.Nanosecond)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want 2006-01-02, RFC 3339 or a duration like 24h)", value)
+}
+
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	status := fs.String("status", "", "only runs with this outcome (fixed, no_fix, declined, ..., or failed)")
+	command := fs.String("command", "", "only runs whose command contains this text")
+	since := fs.String("since", "", "only runs since a date or a duration ago (e.g. 2026-10-01, 24h)")
+	until := fs.String("until", "", "only runs until a date, inclusive, or a duration ago")
+	limit := fs.Int("limit", 20, "show at most this many runs (0 for all)")
+	stats := fs.Bool("stats", false, "show the most common error types and fixes")
+	output := fs.String("output", outputText, "output format: text or json")
+	fs.Parse(args)
+
+	if *output != outputText && *output != outputJSON {
+		fmt.Printf("Error: unknown output format %q (want text or json)\n", *output)
+		return 2
+	}
+	asJSON := *output == outputJSON
+
+	filter := history.Filter{Status: *status, Command: *command}
+	var err error
+	if *since != "" {
+		if filter.Since, err = parseTime(*since, false); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 2
+		}
+	}
+	if *until != "" {
+		if filter.Until, err = parseTime(*until, true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 2
+		}
+	}
+
+	store, err := historyStore()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	runs, err := store.List(filter)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	if *stats {
+		summary := history.Summarize(runs)
+		if asJSON {
+			writeJSON(summary)
+			return 0
+		}
+		printStats(summary)
+		return 0
+	}
+
+	if *limit > 0 && len(runs) > *limit {
+		runs = runs[:*limit]
+	}
+	if asJSON {
+		writeJSON(runs)
+		return 0
+	}
+	if len(runs) == 0 {
+		fmt.Println("No runs found")
+		return 0
+	}
+	for _, run := range runs {
+		fmt.Printf("%-22s %s  %-11s %3d  %s\n", run.ID, run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.Outcome, len(run.Attempts), run.Command)
+	}
+	return 0
+}
+
+func printStats(stats *history.Stats) {
+	fmt.Printf("Runs: %d\n", stats.Runs)
+	fmt.Println("[Outcomes]")
+	for _, c := range stats.Outcomes {
+		fmt.Printf("  %5d  %s\n", c.Count, c.Key)
+	}
+	fmt.Println("[Error Types]")
+	for _, c := range stats.ErrorTypes {
+		fmt.Printf("  %5d  %s\n", c.Count, c.Key)
+	}
+	fmt.Println("[Fixes]")
+	for _, f := range stats.Fixes {
+		fmt.Printf("  %5d  %5d fixed  %s\n", f.Applied, f.Fixed, f.Fix)
+	}
+}
+
+func runShow(args []string) int {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	output := fs.String("output", outputText, "output format: text, or json for the stored record")
+	fs.Parse(args)
+
+	if *output != outputText && *output != outputJSON {
+		fmt.Printf("Error: unknown output format %q (want text or json)\n", *output)
+		return 2
+	}
+	asJSON := *output == outputJSON
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: autofix show [--output json] <run-id>")
+		return 2
+	}
+	store, err := historyStore()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	run, err := store.Find(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		writeJSON(run)
+		return 0
+	}
+
+	fmt.Printf("Run:       %s\n", run.ID)
+	fmt.Printf("Started:   %s (%s)\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.Duration.Round(time.Millisecond))
+	fmt.Printf("Directory: %s\n", run.Dir)
+	if run.Host != "" {
+		fmt.Printf("Host:      %s\n", run.Host)
+	}
+	fmt.Printf("Command:   %s\n", run.Command)
+	fmt.Printf("Outcome:   %s (exit %d)\n", run.Outcome, run.ExitCode)
+	if run.Error != "" {
+		fmt.Printf("Error:     %s\n", run.Error)
+	}
+
+	fmt.Println("[Timeline]")
+	for _, entry := range run.Timeline {
+		offset := entry.Time.Sub(run.StartedAt).Seconds()
+		lines := strings.Split(entry.Text, "\n")
+		fmt.Printf("  +%7.2fs  %-20s %s\n", offset, entry.Event, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Printf("  %9s  %-20s %s\n", "", "", line)
+		}
+	}
+	if j, err := journal.Load(run.ID); err == nil && len(j.Entries) > 0 && j.UndoneAt == nil {
+		fmt.Printf("Undo with: autofix undo %s\n", run.ID)
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value string
+		end   bool
+		want  time.Time
+	}{
+		{value: "2026-10-01", want: time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)},
+		{value: "2026-10-01", end: true, want: time.Date(2026, 10, 1, 23, 59, 59, 999999999, time.Local)},
+		{value: "2026-10-01 14:30", end: true, want: time.Date(2026, 10, 1, 14, 30, 59, 999999999, time.Local)},
+		{value: "2026-10-01T14:30:00Z", end: true, want: time.Date(2026, 10, 1, 14, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.value, tt.end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q, %v) = %s, want %s", tt.value, tt.end, got, tt.want)
+		}
+	}
+
+	if got, err := parseTime("24h", true); err != nil || time.Since(got) < 24*time.Hour-time.Minute {
+		t.Errorf("parseTime(24h) = %s, %v", got, err)
+	}
+	if _, err := parseTime("yesterday", false); err == nil {
+		t.Error("parsed \"yesterday\"")
+	}
+}
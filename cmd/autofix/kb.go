@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/kb"
+)
+
+func runKB(args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: autofix kb list|show <id>|forget <id|fingerprint>")
+		return 1
+	}
+
+	dir, err := kb.DefaultDir()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	store := kb.Open(dir)
+
+	switch args[0] {
+	case "list":
+		entries, err := store.All()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		if len(entries) == 0 {
+			fmt.Println("Knowledge base is empty")
+			return 0
+		}
+		for _, e := range entries {
+			fmt.Printf("%s  %-20s %s/%s  %d/%d  %s\n", e.ID, e.ErrorType, e.Environment.OS, e.Environment.PackageManager,
+				e.Successes, e.Successes+e.Failures, e.FixKey)
+		}
+		return 0
+	case "show":
+		if len(args) < 2 {
+			fmt.Println("Usage: autofix kb show <id>")
+			return 1
+		}
+		e, err := store.Find(args[1])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		printKBEntry(e)
+		return 0
+	case "forget":
+		if len(args) < 2 {
+			fmt.Println("Usage: autofix kb forget <id|fingerprint>")
+			return 1
+		}
+		removed, err := store.Forget(args[1])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Removed %d entries\n", removed)
+		return 0
+	default:
+		fmt.Printf("Unknown kb command: %s\n", args[0])
+		return 1
+	}
+}
+
+func printKBEntry(e *kb.Entry) {
+	fmt.Printf("ID: %s\n", e.ID)
+	fmt.Printf("Fingerprint: %s\n", e.Fingerprint)
+	fmt.Printf("Error: %s (%s)\n", e.ErrorType, e.Message)
+	if e.Subject != "" {
+		fmt.Printf("Subject: %s\n", e.Subject)
+	}
+	fmt.Printf("Environment: %s %s, %s, %s\n", e.Environment.OS, e.Environment.OSVersion, e.Environment.PackageManager, e.Environment.Architecture)
+	fmt.Printf("Outcomes: %d succeeded, %d failed\n", e.Successes, e.Failures)
+	fmt.Printf("First Seen: %s\n", e.FirstSeen.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Last Used: %s\n", e.LastUsed.Format("2006-01-02 15:04:05"))
+
+	var plan fixengine.FixPlan
+	if err := json.Unmarshal(e.Plan, &plan); err == nil {
+		fmt.Println("Fix:")
+		for i, step := range plan.Steps {
+			fmt.Printf("  %d. %s\n", i+1, step)
+		}
+	}
+}
@@ -1,23 +1,42 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/autofix/cli/internal/cassette"
 	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/dotenv"
 	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
 	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/history"
+	"github.com/autofix/cli/internal/journal"
+	"github.com/autofix/cli/internal/kb"
 	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/prompt"
 	"github.com/autofix/cli/internal/safety"
+	"github.com/autofix/cli/internal/workflow"
 )
 
 const Version = "1.0.0"
 
+// stdout receives human-readable output. It is redirected to stderr when
+// --output asks for JSON on stdout.
+var stdout io.Writer = os.Stdout
+
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
-		os.Exit(1)
+		os.Exit(2)
+	}
+
+	if os.Args[1] == executor.SandboxInitCommand {
+		executor.SandboxInit()
 	}
 
 	config.Init()
@@ -26,17 +45,44 @@ func main() {
 
 	switch command {
 	case "run":
+		opts := parseRunOptions(os.Args[2:])
+		os.Exit(runCommand(opts))
+	case "replay":
 		if len(os.Args) < 3 {
-			fmt.Println("Error: command required")
-			printUsage()
-			os.Exit(1)
+			fmt.Println("Error: replay requires a cassette file")
+			fmt.Println("Usage: autofix replay <cassette.json>")
+			os.Exit(2)
 		}
-		runCommand(strings.Join(os.Args[2:], " "))
+		os.Exit(runReplay(os.Args[2]))
+	case "recipes":
+		os.Exit(runRecipes(os.Args[2:]))
+	case "install-binary":
+		os.Exit(runInstallBinary(os.Args[2:]))
+	case "kb":
+		os.Exit(runKB(os.Args[2:]))
+	case "explain":
+		os.Exit(runExplain(os.Args[2:]))
+	case "ensure":
+		os.Exit(runEnsure(os.Args[2:]))
+	case "up":
+		os.Exit(runUp(os.Args[2:]))
+	case "script":
+		os.Exit(runScript(os.Args[2:]))
+	case "shell-init":
+		os.Exit(runShellInit(os.Args[2:]))
+	case "hook":
+		os.Exit(runHook(os.Args[2:]))
+	case "history":
+		os.Exit(runHistory(os.Args[2:]))
+	case "show":
+		os.Exit(runShow(os.Args[2:]))
+	case "undo":
+		os.Exit(runUndo(os.Args[2:]))
 	case "config":
 		if len(os.Args) < 4 {
 			fmt.Println("Error: config command requires key and value")
 			fmt.Println("Usage: autofix config <key> <value>")
-			os.Exit(1)
+			os.Exit(2)
 		}
 		if err := config.Set(os.Args[2], os.Args[3]); err != nil {
 			fmt.Printf("Error: %v\n", err)
@@ -50,7 +96,7 @@ func main() {
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		printUsage()
-		os.Exit(1)
+		os.Exit(2)
 	}
 }
 
@@ -58,7 +104,37 @@ func printUsage() {
 	fmt.Println("AutoFix - Self-healing DevOps Assistant")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("  autofix run <command>    Execute command with auto-healing")
+	fmt.Println("  autofix run [options] <command>  Execute command with auto-healing")
+	fmt.Println("      --cwd DIR            Working directory for the command and its fixes")
+	fmt.Println("      --env KEY=VAL        Set an environment variable (repeatable)")
+	fmt.Println("      --env-file FILE      Load environment variables from a dotenv file")
+	fmt.Println("      --host HOST          Run on a remote host over ssh")
+	fmt.Println("      --sandbox            Trial LLM fixes in a disposable sandbox first")
+	fmt.Println("      --record FILE        Record the session to a cassette file")
+	fmt.Println("      --dry-run            Run the command once and print the fix plan only")
+	fmt.Println("      --log FILE           With --dry-run, plan from a captured log instead")
+	fmt.Println("      --max-attempts N     Maximum fix attempts (default from retry.max_attempts)")
+	fmt.Println("      --backoff DUR        Wait between retries, doubling each time (e.g. 2s)")
+	fmt.Println("      --budget DUR         Give up once retries have taken this long")
+	fmt.Println("      --llm-on-retry       Consult the LLM on later attempts too")
+	fmt.Println("      --yes                Answer yes to every confirmation")
+	fmt.Println("      --no-input           Never prompt; decline anything that needs confirmation")
+	fmt.Println("      --output FORMAT      text (default), json or ndjson on stdout; see Exit Codes")
+	fmt.Println("      --events FILE        Stream engine events to FILE as NDJSON")
+	fmt.Println("      --log-file FILE      Append a structured event log to FILE (default from log.file)")
+	fmt.Println("  autofix replay <cassette>       Replay a recorded session without running anything")
+	fmt.Println("  autofix explain [options] [file|-]  Classify a captured failure log and show the fix it would get")
+	fmt.Println("  autofix ensure [--check] [requirement...]  Install what autofix.yaml requires (e.g. \"node >= 18\") if missing or outdated")
+	fmt.Println("  autofix up [--list] [step...]   Run the steps in autofix.yaml (and their dependencies), healing each")
+	fmt.Println("  autofix script [--resume] <file.sh>  Run a shell script step by step, healing failed steps")
+	fmt.Println("  autofix shell-init bash|zsh|fish  Print a shell hook that offers autofix after a failed command")
+	fmt.Println("  autofix history [options]       List past runs (--status, --command, --since, --until, --limit, --stats)")
+	fmt.Println("  autofix show <run-id>           Show the full timeline of a past run")
+	fmt.Println("  autofix undo [--yes] [run-id]   Reverse the fixes applied by a run (default: latest)")
+	fmt.Println("  autofix undo --list             List journaled runs")
+	fmt.Println("  autofix kb list|show <id>|forget <id>  Manage the local knowledge base of fixes")
+	fmt.Println("  autofix recipes list|test|import|export  Manage shared fix recipes")
+	fmt.Println("  autofix install-binary <name>   Download a configured static binary into ~/.autofix/bin")
 	fmt.Println("  autofix config <key> <value>  Set configuration")
 	fmt.Println("  autofix setup           Interactive setup")
 	fmt.Println("  autofix version         Show version")
@@ -66,44 +142,302 @@ func printUsage() {
 	fmt.Println("Examples:")
 	fmt.Println("  autofix run 'npm install'")
 	fmt.Println("  autofix run 'pip install requests'")
+	fmt.Println("  autofix run --cwd ./web --env-file .env 'npm run build'")
+	fmt.Println("  autofix run --host build-01 make")
 	fmt.Println("  autofix config set llm.api_key sk-...")
 }
 
-func runCommand(cmd string) {
-	fmt.Println("[Detecting Environment]")
-	environment := env.Detect()
-	fmt.Printf("OS: %s\n", environment.OS)
-	fmt.Printf("Architecture: %s\n", environment.Architecture)
-	fmt.Printf("Package Manager: %s\n", environment.PackageManager)
-	fmt.Printf("Has Sudo: %v\n", environment.HasSudo)
-	fmt.Printf("In Container: %v\n", environment.InContainer)
+type envFlags []string
+
+func (e *envFlags) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected KEY=VAL, got %q", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+type runOptions struct {
+	Command string
+	Dir     string
+	Env     []string
+	EnvFile string
+	Host    string
+	Sandbox bool
+	Record  string
+	DryRun  bool
+	Log     string
+	Retry   config.RetryPolicy
+	Yes     bool
+	NoInput bool
+	Events  string
+	LogFile string
+	Output  string
+	// Captured is output the shell hook already captured for Command.
+	Captured *executor.Result
+	// Shell runs Command instead of splitting it into words, for command
+	// lines typed at an interactive shell.
+	Shell string
+}
+
+func parseRunOptions(args []string) *runOptions {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	dir := fs.String("cwd", "", "working directory for the command")
+	envFile := fs.String("env-file", "", "dotenv file to load")
+	host := fs.String("host", "", "run on a remote host over ssh")
+	sandbox := fs.Bool("sandbox", false, "trial LLM fixes in a disposable sandbox first")
+	record := fs.String("record", "", "record the session to a cassette file")
+	dryRun := fs.Bool("dry-run", false, "print the fix plan without running any fix")
+	capturedLog := fs.String("log", "", "with --dry-run, analyze a captured log instead of running the command")
+	maxAttempts := fs.Int("max-attempts", 0, "maximum fix attempts")
+	backoff := fs.Duration("backoff", 0, "initial delay between retries, doubled each time")
+	budget := fs.Duration("budget", 0, "total time budget for retries")
+	llmOnRetry := fs.Bool("llm-on-retry", false, "consult the LLM on later attempts too")
+	yes := fs.Bool("yes", false, "answer yes to every confirmation")
+	noInput := fs.Bool("no-input", false, "never prompt; decline anything that needs confirmation")
+	output := fs.String("output", outputText, "output format: text, json or ndjson")
+	eventsFile := fs.String("events", "", "stream engine events to a file as NDJSON")
+	eventLog := fs.String("log-file", "", "append a structured log of engine events to a file")
+	var envs envFlags
+	fs.Var(&envs, "env", "environment variable KEY=VAL (repeatable)")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		// Fixes are planned for a command, so a captured log still needs
+		// the command that produced it.
+		if *capturedLog != "" {
+			fmt.Println("Error: --log needs the command that produced the log, as in: autofix run --dry-run --log build.log make")
+		} else {
+			fmt.Println("Error: command required")
+		}
+		printUsage()
+		os.Exit(2)
+	}
+
+	opts := &runOptions{
+		Command: strings.Join(fs.Args(), " "),
+		Dir:     *dir,
+		EnvFile: *envFile,
+		Host:    *host,
+		Sandbox: *sandbox || config.Get().Safety.SandboxTrial,
+		Record:  *record,
+		DryRun:  *dryRun || *capturedLog != "",
+		Log:     *capturedLog,
+		Yes:     *yes,
+		NoInput: *noInput,
+		Events:  *eventsFile,
+		LogFile: *eventLog,
+		Output:  *output,
+		Retry: config.RetryPolicy{
+			MaxAttempts: *maxAttempts,
+			Backoff:     *backoff,
+			Budget:      *budget,
+		},
+	}
+	if *llmOnRetry {
+		opts.Retry.LLMOnRetry = llmOnRetry
+	}
+
+	switch opts.Output {
+	case outputText, outputJSON, outputNDJSON:
+	default:
+		fmt.Printf("Error: unknown output format %q (want text, json or ndjson)\n", opts.Output)
+		os.Exit(2)
+	}
+
+	if opts.EnvFile != "" {
+		vars, err := dotenv.Load(opts.EnvFile)
+		if err != nil {
+			fmt.Printf("Error: failed to load %s: %v\n", opts.EnvFile, err)
+			os.Exit(1)
+		}
+		opts.Env = append(opts.Env, vars...)
+	}
+	opts.Env = append(opts.Env, envs...)
+
+	return opts
+}
+
+func runCommand(opts *runOptions) int {
+	cmd := opts.Command
+
+	// Machine-readable output owns stdout; human output moves to stderr.
+	if opts.Output != outputText {
+		stdout = os.Stderr
+	}
+
+	bus, closeEvents, err := newEventBus(opts)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 1
+	}
+	defer closeEvents()
+
+	var ex executor.Executor = executor.NewLocal()
+	var environment *env.Environment
 
+	if opts.Host != "" {
+		ex = executor.NewSSH(opts.Host)
+		fmt.Fprintf(stdout, "[Detecting Environment] %s\n", opts.Host)
+		environment = env.DetectRemote(ex)
+	} else {
+		fmt.Fprintln(stdout, "[Detecting Environment]")
+		environment = env.Detect()
+		if opts.Shell != "" {
+			ex = &workflow.Runner{Executor: ex, Command: cmd, Shell: opts.Shell}
+		}
+	}
 	cfg := config.Get()
+	runID := journal.NewRunID()
+	if cfg.History.Enabled && !opts.DryRun {
+		recorder := history.NewRecorder(runID, runDir(opts.Dir))
+		bus.Subscribe(recorder)
+		defer saveHistory(recorder)
+	}
+	bus.Emit(events.EnvironmentDetected{Host: opts.Host, Environment: environment})
+
+	// finish ends a run that stopped before the engine could start.
+	finish := func(outcome fixengine.Outcome, err error) int {
+		fmt.Fprintf(stdout, "[Error] %v\n", err)
+		bus.Emit(events.SessionFinished{Command: cmd, Outcome: string(outcome), ExitCode: outcome.ExitCode(), Error: err.Error()})
+		return outcome.ExitCode()
+	}
 
 	llmClient := llm.NewClient(cfg.LLM.Provider, cfg.LLM.APIKey, cfg.LLM.Endpoint, cfg.LLM.Model)
+	prompter := newPrompter(opts.Yes, opts.NoInput)
+
+	var host fixengine.Host
+	if opts.Record != "" {
+		c := cassette.New(cmd, environment)
+		c.Dir = opts.Dir
+		c.Env = opts.Env
+		c.Safety = cassette.Safety{
+			AutoExecute:        cfg.Safety.AutoExecute,
+			RequireSudoConfirm: cfg.Safety.RequireSudoConfirm,
+		}
+		c.Retry = cfg.Retry.Overlay(opts.Retry)
+		recorder := cassette.NewRecorder(c)
+		ex = recorder.Executor(ex)
+		llmClient = recorder.LLMClient(llmClient)
+		prompter = recorder.Prompter(prompter)
+		host = recorder
+		defer func() {
+			if err := c.Save(opts.Record); err != nil {
+				fmt.Fprintf(stdout, "[Record] failed to save cassette: %v\n", err)
+				return
+			}
+			fmt.Fprintf(stdout, "[Record] cassette saved to %s\n", opts.Record)
+		}()
+	}
 
-	fixEngine := fixengine.New(environment, llmClient)
+	fixEngine := fixengine.New(environment, llmClient, ex)
+	fixEngine.Events = bus
+	fixEngine.Dir = opts.Dir
+	fixEngine.Env = opts.Env
+	fixEngine.EnvFile = opts.EnvFile
+	fixEngine.Prompter = prompter
+	fixEngine.Host = host
+	fixEngine.Retry = cfg.Retry.Overlay(opts.Retry)
+	fixEngine.Captured = opts.Captured
+
+	fixEngine.Recipes = loadRecipes()
+
+	if cfg.KB.Enabled {
+		if dir, err := kb.DefaultDir(); err == nil {
+			fixEngine.KB = kb.Open(dir)
+		}
+	}
+
+	if !opts.DryRun {
+		j, err := journal.New(runID, cmd, opts.Dir, opts.Host)
+		if err != nil {
+			return finish(fixengine.OutcomeError, fmt.Errorf("journal: %w", err))
+		}
+		fixEngine.Journal = j
+		defer func() {
+			if len(j.Entries) > 0 {
+				fmt.Fprintf(stdout, "[Journal] run %s recorded; undo with: autofix undo %s\n", j.RunID, j.RunID)
+			}
+		}()
+	}
+
+	if opts.Sandbox && opts.Host == "" {
+		sandbox, err := executor.NewSandbox(cfg.Safety.SandboxRootfs)
+		if err != nil {
+			return finish(fixengine.OutcomeError, fmt.Errorf("sandbox: %w", err))
+		}
+		defer sandbox.Close()
+		fixEngine.Sandbox = sandbox
+	}
 
 	validator := safety.NewValidator()
 	if err := validator.Validate(cmd); err != nil {
-		fmt.Printf("[Safety Check] %v\n", err)
-		os.Exit(1)
+		return finish(fixengine.OutcomeBlocked, fmt.Errorf("safety check: %w", err))
 	}
 
-	fmt.Println("[Executing Command]")
-	fmt.Printf("Command: %s\n", cmd)
+	if opts.DryRun {
+		return runDryRun(fixEngine, opts)
+	}
 
-	result, err := fixEngine.ExecuteWithRetry(cmd, 0)
-	if err != nil {
-		fmt.Printf("[Error] %v\n", err)
-		os.Exit(1)
+	if opts.Captured != nil {
+		fmt.Fprintln(stdout, "[Using Captured Output]")
+	} else {
+		fmt.Fprintln(stdout, "[Executing Command]")
 	}
+	fmt.Fprintf(stdout, "Command: %s\n", cmd)
 
-	if result.Success {
-		fmt.Println("[Success]")
+	result, err := fixEngine.ExecuteWithRetry(cmd)
+	printSessionSummary(fixEngine.Session)
+	if err != nil {
+		fmt.Fprintf(stdout, "[Error] %v\n", err)
+	} else if result.Success {
+		fmt.Fprintln(stdout, "[Success]")
 	} else {
-		fmt.Println("[Failed]")
-		os.Exit(result.ExitCode)
+		fmt.Fprintln(stdout, "[Failed]")
+	}
+	return fixEngine.Session.Outcome.ExitCode()
+}
+
+func newPrompter(yes, noInput bool) prompt.Prompter {
+	var p prompt.Prompter
+	switch {
+	case yes:
+		return prompt.AlwaysYes{}
+	case noInput:
+		p = prompt.AlwaysNo{}
+	case prompt.IsCI():
+		fmt.Fprintln(stdout, "[Prompt] CI detected; declining confirmations (use --yes to accept them)")
+		p = prompt.AlwaysNo{}
+	default:
+		p = prompt.NewTerminal(os.Stdin, stdout)
+	}
+
+	cfg := config.Get()
+	if cfg.Prompt.MaxRisk != "" {
+		return &prompt.Policy{MaxRisk: llm.RiskLevel(cfg.Prompt.MaxRisk), AllowSudo: cfg.Prompt.AllowSudo, Otherwise: p}
+	}
+	return p
+}
+
+func printSessionSummary(session *fixengine.Session) {
+	if session == nil || len(session.Errors) == 0 {
+		return
+	}
+
+	fmt.Fprintln(stdout, "[Session Summary]")
+	for _, record := range session.Errors {
+		switch record.Status {
+		case fixengine.StatusResolved:
+			fmt.Fprintf(stdout, "  %s (%s): resolved by %s\n", record.Type, record.Fingerprint, record.ResolvedBy)
+		case fixengine.StatusRegressed:
+			fmt.Fprintf(stdout, "  %s (%s): regressed after %s\n", record.Type, record.Fingerprint, record.RegressedBy)
+		default:
+			fmt.Fprintf(stdout, "  %s (%s): unresolved\n", record.Type, record.Fingerprint)
+		}
 	}
 }
 
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/kb"
+	"github.com/autofix/cli/internal/recipes"
+)
+
+func recipePaths() []string {
+	paths := []string{}
+	if dir, err := recipes.DefaultDir(); err == nil {
+		paths = append(paths, dir)
+	}
+	return append(paths, config.Get().Recipes.Paths...)
+}
+
+func loadRecipes() []*recipes.Recipe {
+	list, err := recipes.LoadAll(recipePaths())
+	if err != nil {
+		fmt.Fprintf(stdout, "[Recipes] %v\n", err)
+		return nil
+	}
+	return list
+}
+
+func runRecipes(args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: autofix recipes list|test [path]|import <file>|export [--from-kb] <file>")
+		return 1
+	}
+
+	switch args[0] {
+	case "list":
+		list, err := recipes.LoadAll(recipePaths())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		if len(list) == 0 {
+			fmt.Println("No recipes found")
+			return 0
+		}
+		for _, r := range list {
+			fmt.Printf("%-30s %-20s %s\n", r.Name, describeMatch(r), r.Source)
+		}
+		return 0
+	case "test":
+		paths := recipePaths()
+		if len(args) > 1 {
+			paths = args[1:]
+		}
+		list, err := recipes.LoadAll(paths)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		return testRecipes(list)
+	case "import":
+		if len(args) < 2 {
+			fmt.Println("Usage: autofix recipes import <file>")
+			return 1
+		}
+		return importRecipes(args[1])
+	case "export":
+		if len(args) > 2 && args[1] == "--from-kb" {
+			return exportKB(args[2])
+		}
+		if len(args) < 2 {
+			fmt.Println("Usage: autofix recipes export [--from-kb] <file>")
+			return 1
+		}
+		list, err := recipes.LoadAll(recipePaths())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		return writeBundle(args[1], list)
+	default:
+		fmt.Printf("Unknown recipes command: %s\n", args[0])
+		return 1
+	}
+}
+
+func describeMatch(r *recipes.Recipe) string {
+	if r.Match.ErrorType != "" {
+		return r.Match.ErrorType
+	}
+	return "pattern"
+}
+
+func testRecipes(list []*recipes.Recipe) int {
+	results := recipes.RunTests(list)
+	if len(results) == 0 {
+		fmt.Println("No recipe tests found")
+		return 0
+	}
+
+	failed := 0
+	for _, result := range results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%s  %s (expected match=%v, got %v)\n", status, result.Recipe.Name, !result.Test.NoMatch, result.Got)
+	}
+
+	fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func importRecipes(path string) int {
+	list, err := recipes.LoadFile(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if code := testRecipes(list); code != 0 {
+		fmt.Println("Error: recipe tests failed, not importing")
+		return code
+	}
+
+	dir, err := recipes.DefaultDir()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	target := filepath.Join(dir, filepath.Base(path))
+	if code := writeBundle(target, list); code != 0 {
+		return code
+	}
+	fmt.Printf("Imported %d recipes into %s\n", len(list), target)
+	return 0
+}
+
+func exportKB(path string) int {
+	dir, err := kb.DefaultDir()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	entries, err := kb.Open(dir).All()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	list := []*recipes.Recipe{}
+	for _, e := range entries {
+		var plan fixengine.FixPlan
+		if err := json.Unmarshal(e.Plan, &plan); err != nil || e.Successes == 0 {
+			continue
+		}
+		r, err := recipeFromPlan(e, &plan)
+		if err != nil {
+			fmt.Printf("[Recipes] skipping %v\n", err)
+			continue
+		}
+		list = append(list, r)
+	}
+	return writeBundle(path, list)
+}
+
+// recipeFromPlan turns a knowledge base entry into a recipe that matches
+// the error type and the command, package or path the error named. An
+// entry without one would match every error of its type.
+func recipeFromPlan(e *kb.Entry, plan *fixengine.FixPlan) (*recipes.Recipe, error) {
+	if e.Subject == "" {
+		return nil, fmt.Errorf("knowledge base entry %s: it names no command, package or path to match on", e.ID)
+	}
+	r := &recipes.Recipe{
+		Name:        fmt.Sprintf("kb-%s", e.ID),
+		Description: fmt.Sprintf("Exported from knowledge base entry %s (fingerprint %s): %s", e.ID, e.Fingerprint, e.Message),
+		Match:       recipes.Match{ErrorType: e.ErrorType, Stderr: regexp.QuoteMeta(e.Subject)},
+		Environment: recipes.Constraint{
+			OS:             []string{e.Environment.OS},
+			PackageManager: []string{e.Environment.PackageManager},
+		},
+		Fix: recipes.Fix{
+			Type:      plan.Type,
+			RiskLevel: string(plan.RiskLevel),
+		},
+	}
+	for _, step := range plan.Steps {
+		r.Fix.Steps = append(r.Fix.Steps, recipes.Step{
+			Command:      step.Command,
+			Env:          step.Env,
+			RiskLevel:    string(step.RiskLevel),
+			Precondition: step.Precondition,
+			Rollback:     step.Rollback,
+		})
+	}
+	return r, nil
+}
+
+func writeBundle(path string, list []*recipes.Recipe) int {
+	data, err := recipes.Marshal(list)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d recipes to %s\n", len(list), path)
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/kb"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/recipes"
+)
+
+func TestRecipeFromPlanRoundTrip(t *testing.T) {
+	entry := &kb.Entry{
+		ID:          "0a1b2c3d",
+		ErrorType:   string(errorparser.ErrorTypeMissingCommand),
+		Message:     "Command not found",
+		Subject:     "c++",
+		Environment: kb.Environment{OS: "debian", PackageManager: "apt"},
+	}
+	plan := &fixengine.FixPlan{
+		Type:      fixengine.FixTypePreparation,
+		RiskLevel: llm.RiskLow,
+		Steps:     []fixengine.Step{fixengine.NewStep("sudo apt-get install -y g++", "sudo apt-get remove -y g++", llm.RiskLow)},
+	}
+	r, err := recipeFromPlan(entry, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := recipes.Marshal([]*recipes.Recipe{r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := recipes.Parse(data, "bundle.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("parsed %d recipes, want 1", len(list))
+	}
+
+	debian := &env.Environment{OS: env.OSDebian, PackageManager: env.PMApt}
+	tests := []struct {
+		name        string
+		stderr      string
+		environment *env.Environment
+		want        bool
+	}{
+		{name: "same command", stderr: "bash: c++: command not found", environment: debian, want: true},
+		{name: "other command", stderr: "bash: sl: command not found", environment: debian},
+		{name: "other package manager", stderr: "bash: c++: command not found", environment: &env.Environment{OS: env.OSDebian, PackageManager: env.PMBrew}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := errorparser.Parse(tt.stderr, 127)
+			found := recipes.Find(list, info, tt.environment, "make", tt.stderr)
+			if got := len(found) == 1; got != tt.want {
+				t.Errorf("matched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	imported := fixengine.PlanFromRecipe(list[0])
+	if got, want := imported.Commands(), plan.Commands(); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("imported commands = %q, want %q", got, want)
+	}
+}
+
+func TestRecipeFromPlanWithoutSubject(t *testing.T) {
+	entry := &kb.Entry{ID: "0a1b2c3d", ErrorType: string(errorparser.ErrorTypeCertificate)}
+	plan := &fixengine.FixPlan{Steps: []fixengine.Step{fixengine.NewStep("sudo update-ca-certificates", "", llm.RiskLow)}}
+	if _, err := recipeFromPlan(entry, plan); err == nil {
+		t.Error("exported an entry that would match every certificate error")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/autofix/cli/internal/cassette"
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/fixengine"
+)
+
+func runReplay(path string) int {
+	c, err := cassette.Load(path)
+	if err != nil {
+		fmt.Printf("Error: failed to load cassette: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("[Replaying] %s (recorded %s)\n", path, c.RecordedAt.Format("2006-01-02 15:04:05 UTC"))
+	bus := events.NewBus(events.NewConsole(os.Stdout))
+	bus.Emit(events.EnvironmentDetected{Environment: c.Environment})
+
+	cfg := config.Get()
+	cfg.Safety.AutoExecute = c.Safety.AutoExecute
+	cfg.Safety.RequireSudoConfirm = c.Safety.RequireSudoConfirm
+
+	replayer := cassette.NewReplayer(c)
+	replayer.OnReplay = printInteraction
+
+	fixEngine := fixengine.New(c.Environment, replayer.LLMClient(), replayer.Executor())
+	fixEngine.Dir = c.Dir
+	fixEngine.Env = c.Env
+	fixEngine.Prompter = replayer.Prompter()
+	fixEngine.Host = replayer
+	fixEngine.Retry = c.Retry
+	fixEngine.Sleep = func(time.Duration) {}
+	fixEngine.Events = bus
+
+	fmt.Println("[Executing Command]")
+	fmt.Printf("Command: %s\n", c.Command)
+
+	result, err := fixEngine.ExecuteWithRetry(c.Command)
+	printSessionSummary(fixEngine.Session)
+
+	if replayErr := replayer.Err(); replayErr != nil {
+		fmt.Printf("[Replay Diverged] %v\n", replayErr)
+		return 1
+	}
+	if remaining := replayer.Remaining(); remaining > 0 {
+		fmt.Printf("[Replay Diverged] %d recorded interactions were not replayed\n", remaining)
+		return 1
+	}
+
+	if err != nil {
+		fmt.Printf("[Error] %v\n", err)
+	} else if result.Success {
+		fmt.Println("[Success]")
+	} else {
+		fmt.Println("[Failed]")
+	}
+	return fixEngine.Session.Outcome.ExitCode()
+}
+
+func printInteraction(i *cassette.Interaction) {
+	switch i.Kind {
+	case cassette.KindExec:
+		if i.Result != nil {
+			fmt.Printf("[Replay] exec %q -> exit %d\n", i.Exec.Command, i.Result.ExitCode)
+			if i.Result.Stderr != "" {
+				fmt.Printf("  stderr: %s\n", i.Result.Stderr)
+			}
+		}
+	case cassette.KindLLM:
+		if i.Suggestion != nil {
+			fmt.Printf("[Replay] LLM suggested %q (risk %s): %s\n", i.Suggestion.ProposedFix, i.Suggestion.RiskLevel, i.Suggestion.Explanation)
+		} else {
+			fmt.Printf("[Replay] LLM error: %s\n", i.Error)
+		}
+	case cassette.KindPrompt:
+		answer := "no"
+		if i.Answer {
+			answer = "yes"
+		} else if i.Edit {
+			answer = "edit"
+		}
+		fmt.Printf("[Replay] %s -> %s\n", i.Question, answer)
+	case cassette.KindEdit:
+		fmt.Printf("[Replay] edited %q -> %q\n", i.Question, i.Edited)
+	case cassette.KindHost:
+		if string(i.Value) != "null" && string(i.Value) != `""` {
+			fmt.Printf("[Replay] %s -> %s\n", i.Call, i.Value)
+		}
+	}
+}
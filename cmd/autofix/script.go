@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/dotenv"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/script"
+)
+
+func runScript(args []string) int {
+	fs := flag.NewFlagSet("script", flag.ExitOnError)
+	resume := fs.Bool("resume", false, "continue an interrupted run from the step it stopped at")
+	list := fs.Bool("list", false, "print the steps the script splits into and exit")
+	dir := fs.String("cwd", "", "working directory the script starts in")
+	envFile := fs.String("env-file", "", "dotenv file to load")
+	maxAttempts := fs.Int("max-attempts", 0, "maximum fix attempts per step")
+	yes := fs.Bool("yes", false, "answer yes to every confirmation")
+	noInput := fs.Bool("no-input", false, "never prompt; decline anything that needs confirmation")
+	var envs envFlags
+	fs.Var(&envs, "env", "environment variable KEY=VAL (repeatable)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: autofix script [options] <file.sh>")
+		return 2
+	}
+	s, err := script.Load(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if *list {
+		for i, step := range s.Steps {
+			fmt.Printf("%3d  line %-4d %s\n", i+1, step.Line, step.Summary())
+		}
+		return 0
+	}
+
+	state := &script.State{Dir: runDir(*dir)}
+	if *envFile != "" {
+		vars, err := dotenv.Load(*envFile)
+		if err != nil {
+			fmt.Printf("Error: failed to load %s: %v\n", *envFile, err)
+			return 1
+		}
+		state.Env = append(state.Env, vars...)
+	}
+	state.Env = append(state.Env, envs...)
+
+	start := 0
+	saved, err := script.LoadResume(s)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	switch {
+	case *resume && saved == nil:
+		fmt.Printf("Error: no interrupted run of %s to resume\n", s.Path)
+		return 1
+	case *resume:
+		if start, err = saved.Position(s); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		state = &saved.State
+		fmt.Printf("[Script] resuming at step %d (line %d) in %s\n", start+1, s.Steps[start].Line, state.Dir)
+	case saved != nil:
+		fmt.Printf("[Script] a previous run stopped at line %d; starting over (use --resume to continue it)\n", saved.Line)
+	}
+
+	shell, err := script.NewShell(executor.NewLocal(), s.Interpreter, state)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	defer shell.Close()
+
+	fmt.Println("[Detecting Environment]")
+	environment := env.Detect()
+	events.NewConsole(stdout).Handle(events.EnvironmentDetected{Environment: environment})
+
+	fixEngine := newStepEngine(environment, shell, *yes, *noInput)
+	fixEngine.Retry = fixEngine.Retry.Overlay(config.RetryPolicy{MaxAttempts: *maxAttempts})
+
+	for i := start; i < len(s.Steps); i++ {
+		step := s.Steps[i]
+		if err := script.SaveResume(s, i, state); err != nil {
+			fmt.Fprintf(stdout, "[Script] failed to save resume point: %v\n", err)
+		}
+		fmt.Fprintf(stdout, "[Step %d/%d] line %d: %s\n", i+1, len(s.Steps), step.Line, step.Summary())
+		shell.Step = step.Text
+		if code := healStep(fixEngine, step.Text, state.Dir); code != 0 {
+			fmt.Fprintf(stdout, "[Script] stopped at step %d (line %d); continue with: autofix script --resume %s\n", i+1, step.Line, fs.Arg(0))
+			return code
+		}
+	}
+
+	if err := script.RemoveResume(s); err != nil {
+		fmt.Fprintf(stdout, "[Script] failed to remove resume point: %v\n", err)
+	}
+	fmt.Fprintf(stdout, "[Script] completed %d steps\n", len(s.Steps))
+	return 0
+}
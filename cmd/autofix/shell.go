@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/autofix/cli/internal/prompt"
+)
+
+// captureWrapper is the shell function that tees a command's stderr to the
+// capture file so the hook can classify it without running it again.
+const captureWrapper = "autofix-capture"
+
+// quietCommands exit non-zero to report a result rather than a failure.
+var quietCommands = map[string]bool{
+	"grep": true, "egrep": true, "fgrep": true, "rg": true, "ag": true,
+	"diff": true, "cmp": true, "test": true, "[": true, "[[": true,
+	"false": true, "which": true, "type": true, "command": true,
+	"autofix": true, "exit": true, "man": true, "less": true,
+}
+
+const bashHook = `# autofix shell integration for bash
+# Add to ~/.bashrc: eval "$(autofix shell-init bash)"
+__autofix_capture=$(mktemp "${TMPDIR:-/tmp}/autofix.XXXXXX")
+__autofix_histnum=""
+__autofix_primed=""
+[[ -z $(trap -p EXIT) ]] && trap 'rm -f "$__autofix_capture"' EXIT
+
+autofix-capture() {
+    local rc
+    exec 3>&1
+    "$@" 2>&1 1>&3 3>&- | tee "$__autofix_capture" >&2
+    rc=${PIPESTATUS[0]}
+    exec 3>&-
+    return "$rc"
+}
+
+if declare -f command_not_found_handle >/dev/null && ! declare -f command_not_found_handle | grep -q __autofix_capture; then
+    eval "__autofix_previous_cnf () $(declare -f command_not_found_handle | tail -n +2)"
+fi
+
+command_not_found_handle() {
+    printf 'bash: %s: command not found\n' "$1" >"$__autofix_capture"
+    if declare -f __autofix_previous_cnf >/dev/null; then
+        __autofix_previous_cnf "$@"
+        return $?
+    fi
+    cat "$__autofix_capture" >&2
+    return 127
+}
+
+__autofix_precmd() {
+    local rc=$? num line last
+    read -r num line <<<"$(HISTTIMEFORMAT= builtin history 1)"
+    last=$__autofix_histnum
+    __autofix_histnum=$num
+    # The first prompt follows the startup files, not a typed command.
+    if [[ $rc -ne 0 && -n $__autofix_primed && $num != "$last" ]]; then
+        if [[ -s $__autofix_capture ]]; then
+            __AUTOFIX__ hook --shell "$BASH" --exit-code "$rc" --stderr "$__autofix_capture" -- "$line"
+        else
+            __AUTOFIX__ hook --shell "$BASH" --exit-code "$rc" -- "$line"
+        fi
+    fi
+    __autofix_primed=1
+    : >"$__autofix_capture"
+    return "$rc"
+}
+
+if [[ ${PROMPT_COMMAND:-} != *__autofix_precmd* ]]; then
+    PROMPT_COMMAND="__autofix_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHook = `# autofix shell integration for zsh
+# Add to ~/.zshrc: eval "$(autofix shell-init zsh)"
+typeset -g __autofix_capture=$(mktemp "${TMPDIR:-/tmp}/autofix.XXXXXX")
+typeset -g __autofix_command=""
+
+autofix-capture() {
+    setopt localoptions nomultios
+    local rc
+    exec 3>&1
+    "$@" 2>&1 1>&3 3>&- | tee "$__autofix_capture" >&2
+    rc=${pipestatus[1]}
+    exec 3>&-
+    return $rc
+}
+
+if (( $+functions[command_not_found_handler] )) && [[ $functions[command_not_found_handler] != *__autofix_capture* ]]; then
+    functions[__autofix_previous_cnf]=$functions[command_not_found_handler]
+fi
+
+command_not_found_handler() {
+    print -r -- "zsh: command not found: $1" >"$__autofix_capture"
+    if (( $+functions[__autofix_previous_cnf] )); then
+        __autofix_previous_cnf "$@"
+        return $?
+    fi
+    cat "$__autofix_capture" >&2
+    return 127
+}
+
+__autofix_preexec() {
+    __autofix_command=$1
+}
+
+__autofix_precmd() {
+    local rc=$? cmd=$__autofix_command
+    __autofix_command=""
+    if [[ $rc -ne 0 && -n $cmd ]]; then
+        local -a capture
+        [[ -s $__autofix_capture ]] && capture=(--stderr "$__autofix_capture")
+        __AUTOFIX__ hook --shell zsh --exit-code "$rc" $capture -- "$cmd"
+    fi
+    : >"$__autofix_capture"
+    return $rc
+}
+
+__autofix_exit() {
+    rm -f "$__autofix_capture"
+}
+
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __autofix_preexec
+add-zsh-hook zshexit __autofix_exit
+precmd_functions=(__autofix_precmd ${precmd_functions:#__autofix_precmd})
+`
+
+const fishHook = `# autofix shell integration for fish
+# Add to ~/.config/fish/config.fish: autofix shell-init fish | source
+set -l __autofix_tmp /tmp
+set -q TMPDIR; and set __autofix_tmp $TMPDIR
+set -g __autofix_capture (mktemp $__autofix_tmp/autofix.XXXXXX)
+
+function autofix-capture
+    $argv 2>| tee $__autofix_capture 1>&2
+    return $pipestatus[1]
+end
+
+if functions -q fish_command_not_found; and not functions -q __autofix_previous_cnf
+    functions -c fish_command_not_found __autofix_previous_cnf
+end
+
+function fish_command_not_found
+    echo "fish: command not found: $argv[1]" >$__autofix_capture
+    if functions -q __autofix_previous_cnf
+        __autofix_previous_cnf $argv
+    else
+        cat $__autofix_capture >&2
+    end
+end
+
+function __autofix_postexec --on-event fish_postexec
+    set -l rc $status
+    if test $rc -ne 0; and test -n "$argv[1]"
+        set -l capture
+        test -s $__autofix_capture; and set capture --stderr $__autofix_capture
+        __AUTOFIX__ hook --shell fish --exit-code $rc $capture -- $argv[1]
+    end
+    printf '' >$__autofix_capture
+end
+
+function __autofix_exit --on-event fish_exit
+    rm -f $__autofix_capture
+end
+`
+
+func runShellInit(args []string) int {
+	if len(args) != 1 {
+		fmt.Println("Usage: autofix shell-init bash|zsh|fish")
+		return 2
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		self = "autofix"
+	} else if resolved, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolved
+	}
+
+	var script string
+	switch args[0] {
+	case "bash":
+		script = strings.ReplaceAll(bashHook, "__AUTOFIX__", shellQuote(self))
+	case "zsh":
+		script = strings.ReplaceAll(zshHook, "__AUTOFIX__", shellQuote(self))
+	case "fish":
+		script = strings.ReplaceAll(fishHook, "__AUTOFIX__", fishQuote(self))
+	default:
+		fmt.Printf("Error: unsupported shell %q (want bash, zsh or fish)\n", args[0])
+		return 2
+	}
+	fmt.Print(script)
+	return 0
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// runHook is called by the shell integration after an interactive command
+// fails. It offers to heal the command and, if accepted, runs it through
+// the engine, starting from the captured stderr when there is one.
+func runHook(args []string) int {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	exitCode := fs.Int("exit-code", 1, "the failed command's exit status")
+	stderrFile := fs.String("stderr", "", "file holding the command's captured stderr")
+	shell := fs.String("shell", "", "the interactive shell the command line was typed into")
+	fs.Parse(args)
+
+	command := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	command, wrapped := strings.CutPrefix(command, captureWrapper+" ")
+	command = strings.TrimSpace(command)
+	if !shouldOffer(command, *exitCode) || prompt.IsCI() {
+		return 0
+	}
+
+	terminal := prompt.NewTerminal(os.Stdin, os.Stderr)
+	text := fmt.Sprintf("[AutoFix] %q exited with %d. autofix this?", command, *exitCode)
+	answer, err := terminal.Confirm(&prompt.Question{Kind: prompt.KindOfferFix, Text: text, Commands: []string{command}})
+	if err != nil || answer != prompt.Yes {
+		return 0
+	}
+
+	opts := parseRunOptions([]string{"--", command})
+	opts.Shell = *shell
+	// Only output the wrapper or the not-found handler wrote for this very
+	// command is trusted; anything else means running the command again.
+	if *stderrFile != "" && (wrapped || *exitCode == 127) {
+		captured, err := loadCapturedLog(*stderrFile, command)
+		if err == nil && captured.Stderr != "" {
+			captured.ExitCode = *exitCode
+			opts.Captured = captured
+		}
+	}
+	return runCommand(opts)
+}
+
+func shouldOffer(command string, exitCode int) bool {
+	// 0 is success and statuses above 128 mean the command was killed by
+	// a signal, usually Ctrl-C or Ctrl-Z.
+	if command == "" || exitCode == 0 || exitCode > 128 {
+		return false
+	}
+	fields := strings.Fields(command)
+	name := filepath.Base(fields[0])
+	if name == "sudo" && len(fields) > 1 {
+		name = filepath.Base(fields[1])
+	}
+	return !quietCommands[name]
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/history"
+	"github.com/autofix/cli/internal/journal"
+	"github.com/autofix/cli/internal/kb"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/safety"
+)
+
+// newStepEngine sets up an engine shared by the steps of a script or
+// workflow; each step then adjusts it before healStep runs it.
+func newStepEngine(environment *env.Environment, ex executor.Executor, yes, noInput bool) *fixengine.FixEngine {
+	cfg := config.Get()
+	llmClient := llm.NewClient(cfg.LLM.Provider, cfg.LLM.APIKey, cfg.LLM.Endpoint, cfg.LLM.Model)
+	fixEngine := fixengine.New(environment, llmClient, ex)
+	fixEngine.Prompter = newPrompter(yes, noInput)
+	fixEngine.Recipes = loadRecipes()
+	if cfg.KB.Enabled {
+		if dir, err := kb.DefaultDir(); err == nil {
+			fixEngine.KB = kb.Open(dir)
+		}
+	}
+	return fixEngine
+}
+
+// healStep runs one step of a script or workflow through the engine as
+// its own run, with its own journal and history record.
+func healStep(fixEngine *fixengine.FixEngine, command, dir string) int {
+	cfg := config.Get()
+	runID := journal.NewRunID()
+	bus := events.NewBus()
+	if cfg.History.Enabled {
+		recorder := history.NewRecorder(runID, dir)
+		bus.Subscribe(recorder)
+		bus.Emit(events.EnvironmentDetected{Environment: fixEngine.Environment})
+		defer saveHistory(recorder)
+	}
+	bus.Subscribe(events.NewConsole(stdout))
+	fixEngine.Events = bus
+
+	// The project's author chose its commands, so only the blocklist
+	// applies, not the confirmation rules for sudo.
+	if err := safety.NewValidator().CheckDestructive(command); err != nil {
+		outcome := fixengine.OutcomeBlocked
+		fmt.Fprintf(stdout, "[Error] safety check: %v\n", err)
+		bus.Emit(events.SessionFinished{Command: command, Outcome: string(outcome), ExitCode: outcome.ExitCode(), Error: err.Error()})
+		return outcome.ExitCode()
+	}
+
+	j, err := journal.New(runID, command, dir, "")
+	if err != nil {
+		fmt.Fprintf(stdout, "[Error] journal: %v\n", err)
+		return fixengine.OutcomeError.ExitCode()
+	}
+	fixEngine.Journal = j
+	defer func() {
+		if len(j.Entries) > 0 {
+			fmt.Fprintf(stdout, "[Journal] run %s recorded; undo with: autofix undo %s\n", j.RunID, j.RunID)
+		}
+	}()
+
+	fixEngine.Dir = dir
+	result, err := fixEngine.ExecuteWithRetry(command)
+	if fixEngine.Session.Outcome == fixengine.OutcomeSucceeded {
+		return 0
+	}
+	printSessionSummary(fixEngine.Session)
+	if err != nil {
+		fmt.Fprintf(stdout, "[Error] %v\n", err)
+	} else if !result.Success {
+		fmt.Fprintln(stdout, "[Failed]")
+	}
+	return fixEngine.Session.Outcome.ExitCode()
+}
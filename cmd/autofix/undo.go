@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/journal"
+	"github.com/autofix/cli/internal/prompt"
+)
+
+func runUndo(args []string) int {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	list := fs.Bool("list", false, "list journaled runs")
+	yes := fs.Bool("yes", false, "apply the undo plan without asking")
+	noInput := fs.Bool("no-input", false, "never prompt; only print the undo plan")
+	fs.Parse(args)
+
+	if *list {
+		return listJournals()
+	}
+
+	var j *journal.Journal
+	var err error
+	if fs.NArg() > 0 {
+		j, err = journal.Load(fs.Arg(0))
+	} else {
+		j, err = journal.Latest()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	if j.UndoneAt != nil {
+		fmt.Printf("Run %s was already undone at %s\n", j.RunID, j.UndoneAt.Format("2006-01-02 15:04:05"))
+		return 1
+	}
+
+	actions := j.UndoPlan()
+	fmt.Printf("[Undo] run %s: %s\n", j.RunID, j.Command)
+	if len(actions) == 0 {
+		fmt.Println("Nothing to undo")
+		return 0
+	}
+
+	for i, action := range actions {
+		fmt.Printf("%d. %s\n", i+1, action.Description)
+		if action.Command != "" {
+			fmt.Printf("   %s\n", action.Command)
+		}
+	}
+
+	ok, err := newPrompter(*yes, *noInput).Confirm(&prompt.Question{Kind: prompt.KindUndo, Text: "Apply this undo plan?"})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if ok != prompt.Yes {
+		fmt.Println("Undo cancelled")
+		return 1
+	}
+
+	var ex executor.Executor = executor.NewLocal()
+	if j.Host != "" {
+		ex = executor.NewSSH(j.Host)
+	}
+
+	failed := 0
+	for _, action := range actions {
+		if action.Command == "" {
+			if err := action.ApplyLocal(); err != nil {
+				fmt.Printf("[Undo Failed] %s: %v\n", action.Description, err)
+				failed++
+				continue
+			}
+			fmt.Printf("[Undone] %s\n", action.Description)
+			continue
+		}
+
+		result, err := ex.Run(context.Background(), &executor.Request{Command: action.Command, Dir: j.Dir})
+		if err != nil || !result.Success {
+			fmt.Printf("[Undo Failed] %s\n", action.Command)
+			failed++
+			continue
+		}
+		fmt.Printf("[Undone] %s\n", action.Description)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d undo actions failed; the journal is kept so you can retry\n", failed)
+		return 1
+	}
+
+	if err := j.MarkUndone(); err != nil {
+		fmt.Printf("Error: failed to update journal: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func listJournals() int {
+	journals, err := journal.List()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if len(journals) == 0 {
+		fmt.Println("No journaled runs")
+		return 0
+	}
+
+	for _, j := range journals {
+		status := ""
+		if j.UndoneAt != nil {
+			status = " (undone)"
+		}
+		fmt.Printf("%s  %s  %d entries%s\n", j.RunID, j.Command, len(j.Entries), status)
+	}
+	return 0
+}
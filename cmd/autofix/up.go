@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/workflow"
+)
+
+func runUp(args []string) int {
+	fs := flag.NewFlagSet("up", flag.ExitOnError)
+	file := fs.String("file", "", "workflow file (default: autofix.yaml in this directory or a parent)")
+	list := fs.Bool("list", false, "print the steps that would run, in order, and exit")
+	yes := fs.Bool("yes", false, "answer yes to every confirmation")
+	noInput := fs.Bool("no-input", false, "never prompt; decline anything that needs confirmation")
+	fs.Parse(args)
+
+	path := *file
+	if path == "" {
+		var err error
+		if path, err = workflow.Find("."); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+	}
+	w, err := workflow.Load(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	plan, err := w.Plan(fs.Args())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 2
+	}
+	if *list {
+		for i, step := range plan {
+			fmt.Printf("%3d  %-20s %s\n", i+1, step.Name, step.Command)
+		}
+		return 0
+	}
+
+	fmt.Println("[Detecting Environment]")
+	environment := env.Detect()
+	events.NewConsole(stdout).Handle(events.EnvironmentDetected{Environment: environment})
+
+	local := executor.NewLocal()
+	fixEngine := newStepEngine(environment, local, *yes, *noInput)
+	base := fixEngine.Retry
+
+	if len(w.Requires) > 0 {
+		fixEngine.Events = events.NewBus(events.NewConsole(stdout))
+		if code := ensureRequirements(fixEngine, w.Requires); code != 0 {
+			fmt.Fprintln(stdout, "[Up] requirements are not met; see autofix ensure --check")
+			return code
+		}
+	}
+
+	for i, step := range plan {
+		header := fmt.Sprintf("[Step %d/%d] %s: %s", i+1, len(plan), step.Name, step.Command)
+		if len(step.Needs) > 0 {
+			header += fmt.Sprintf(" (needs %s)", strings.Join(step.Needs, ", "))
+		}
+		fmt.Fprintln(stdout, header)
+
+		dir := w.WorkDir(step)
+		fixEngine.Executor = &workflow.Runner{Executor: local, Command: step.Command, Limit: step.Timeout}
+		fixEngine.Env = w.Environ(step)
+		fixEngine.Retry = base.Overlay(step.Retry)
+		fixEngine.AllowedFixes = step.Fixes
+		fixEngine.Check = nil
+		if !step.Success.IsZero() {
+			check := step.Success
+			fixEngine.Check = func(result *executor.Result) error {
+				return check.Verify(result, dir)
+			}
+		}
+
+		if code := healStep(fixEngine, step.Command, dir); code != 0 {
+			rerun := "autofix up " + step.Name
+			if *file != "" {
+				rerun = fmt.Sprintf("autofix up --file %s %s", *file, step.Name)
+			}
+			fmt.Fprintf(stdout, "[Up] stopped at step %s; rerun it with: %s\n", step.Name, rerun)
+			return code
+		}
+	}
+	fmt.Fprintf(stdout, "[Up] %d steps completed\n", len(plan))
+	return 0
+}
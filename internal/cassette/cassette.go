@@ -0,0 +1,108 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+)
+
+const FormatVersion = 2
+
+type Kind string
+
+const (
+	KindExec   Kind = "exec"
+	KindLLM    Kind = "llm"
+	KindPrompt Kind = "prompt"
+	KindEdit   Kind = "edit"
+	KindHost   Kind = "host"
+)
+
+type ExecRequest struct {
+	Command string   `json:"command"`
+	Dir     string   `json:"dir,omitempty"`
+	Env     []string `json:"env,omitempty"`
+}
+
+type Interaction struct {
+	Kind       Kind             `json:"kind"`
+	Exec       *ExecRequest     `json:"exec,omitempty"`
+	Result     *executor.Result `json:"result,omitempty"`
+	LLMRequest *llm.Request     `json:"llm_request,omitempty"`
+	Suggestion *llm.Suggestion  `json:"suggestion,omitempty"`
+	Question   string           `json:"question,omitempty"`
+	Answer     bool             `json:"answer,omitempty"`
+	Edit       bool             `json:"edit,omitempty"`
+	Edited     string           `json:"edited,omitempty"`
+	Call       string           `json:"call,omitempty"`
+	Value      json.RawMessage  `json:"value,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+type Safety struct {
+	AutoExecute        bool `json:"auto_execute"`
+	RequireSudoConfirm bool `json:"require_sudo_confirm"`
+}
+
+type Cassette struct {
+	Version      int              `json:"version"`
+	RecordedAt   time.Time        `json:"recorded_at"`
+	Command      string           `json:"command"`
+	Dir          string           `json:"dir,omitempty"`
+	Env          []string         `json:"env,omitempty"`
+	Environment  *env.Environment `json:"environment"`
+	Local        bool             `json:"local"`
+	Safety       Safety           `json:"safety"`
+	Retry        config.Retry     `json:"retry"`
+	Interactions []*Interaction   `json:"interactions"`
+
+	mu sync.Mutex
+}
+
+func New(command string, environment *env.Environment) *Cassette {
+	return &Cassette{
+		Version:     FormatVersion,
+		RecordedAt:  time.Now().UTC(),
+		Command:     command,
+		Environment: environment,
+	}
+}
+
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Version != FormatVersion {
+		return nil, fmt.Errorf("cassette format %d is not supported (want %d); record the session again", c.Version, FormatVersion)
+	}
+	return &c, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func (c *Cassette) add(i *Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, i)
+}
@@ -0,0 +1,121 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/prompt"
+)
+
+type Recorder struct {
+	Cassette *Cassette
+
+	// calling is set during a host call; what the call runs is part of
+	// its recorded value and is not recorded on its own.
+	calling bool
+}
+
+func NewRecorder(c *Cassette) *Recorder {
+	return &Recorder{Cassette: c}
+}
+
+func (r *Recorder) Executor(inner executor.Executor) executor.Executor {
+	r.Cassette.Local = executor.IsLocal(inner)
+	return &recordingExecutor{recorder: r, inner: inner}
+}
+
+// Call makes the call on this machine and records what it returned.
+func (r *Recorder) Call(name string, out any, call func() any) error {
+	r.calling = true
+	value := call()
+	r.calling = false
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	r.add(&Interaction{Kind: KindHost, Call: name, Value: data})
+	return json.Unmarshal(data, out)
+}
+
+func (r *Recorder) LLMClient(inner llm.Client) llm.Client {
+	return &recordingClient{recorder: r, inner: inner}
+}
+
+func (r *Recorder) Prompter(inner prompt.Prompter) prompt.Prompter {
+	return &recordingPrompter{recorder: r, inner: inner}
+}
+
+func (r *Recorder) add(i *Interaction) {
+	if !r.calling {
+		r.Cassette.add(i)
+	}
+}
+
+type recordingPrompter struct {
+	recorder *Recorder
+	inner    prompt.Prompter
+}
+
+func (p *recordingPrompter) Confirm(q *prompt.Question) (prompt.Answer, error) {
+	answer, err := p.inner.Confirm(q)
+
+	i := &Interaction{Kind: KindPrompt, Question: q.Text, Answer: answer == prompt.Yes, Edit: answer == prompt.Edit}
+	if err != nil {
+		i.Error = err.Error()
+	}
+	p.recorder.add(i)
+	return answer, err
+}
+
+func (p *recordingPrompter) Edit(command string) (string, error) {
+	edited, err := p.inner.Edit(command)
+
+	i := &Interaction{Kind: KindEdit, Question: command, Edited: edited}
+	if err != nil {
+		i.Error = err.Error()
+	}
+	p.recorder.add(i)
+	return edited, err
+}
+
+type recordingExecutor struct {
+	recorder *Recorder
+	inner    executor.Executor
+}
+
+func (e *recordingExecutor) Run(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+	result, err := e.inner.Run(ctx, req)
+
+	i := &Interaction{
+		Kind:   KindExec,
+		Exec:   &ExecRequest{Command: req.Command, Dir: req.Dir, Env: req.Env},
+		Result: result,
+	}
+	if err != nil {
+		i.Error = err.Error()
+	}
+	e.recorder.add(i)
+	return result, err
+}
+
+func (e *recordingExecutor) Unwrap() executor.Executor {
+	return e.inner
+}
+
+type recordingClient struct {
+	recorder *Recorder
+	inner    llm.Client
+}
+
+func (c *recordingClient) GetSuggestion(req *llm.Request) (*llm.Suggestion, error) {
+	suggestion, err := c.inner.GetSuggestion(req)
+
+	i := &Interaction{Kind: KindLLM, LLMRequest: req, Suggestion: suggestion}
+	if err != nil {
+		i.Error = err.Error()
+	}
+	c.recorder.add(i)
+	return suggestion, err
+}
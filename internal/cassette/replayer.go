@@ -0,0 +1,191 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/prompt"
+)
+
+type MismatchError struct {
+	Index    int
+	Expected string
+	Got      string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("cassette mismatch at interaction %d: expected %s, got %s", e.Index, e.Expected, e.Got)
+}
+
+type Replayer struct {
+	Cassette *Cassette
+	OnReplay func(i *Interaction)
+
+	mu     sync.Mutex
+	cursor int
+	err    error
+}
+
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{Cassette: c}
+}
+
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.Cassette.Interactions) - r.cursor
+}
+
+func (r *Replayer) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Replayer) next(kind Kind, describe string) (*Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cursor >= len(r.Cassette.Interactions) {
+		r.err = &MismatchError{Index: r.cursor, Expected: "end of cassette", Got: describe}
+		return nil, r.err
+	}
+
+	i := r.Cassette.Interactions[r.cursor]
+	if i.Kind != kind {
+		r.err = &MismatchError{Index: r.cursor, Expected: describeInteraction(i), Got: describe}
+		return nil, r.err
+	}
+	r.cursor++
+
+	if r.OnReplay != nil {
+		r.OnReplay(i)
+	}
+	return i, nil
+}
+
+func (r *Replayer) Executor() executor.Executor {
+	return &replayExecutor{replayer: r}
+}
+
+func (r *Replayer) LLMClient() llm.Client {
+	return &replayClient{replayer: r}
+}
+
+func (r *Replayer) Prompter() prompt.Prompter {
+	return &replayPrompter{replayer: r}
+}
+
+// Call returns what the recorded call returned, without making it.
+func (r *Replayer) Call(name string, out any, call func() any) error {
+	describe := "host " + name
+	i, err := r.next(KindHost, describe)
+	if err != nil {
+		return err
+	}
+	if i.Call != name {
+		return r.mismatch(i, describe)
+	}
+	return json.Unmarshal(i.Value, out)
+}
+
+func (r *Replayer) mismatch(i *Interaction, describe string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = &MismatchError{Index: r.cursor - 1, Expected: describeInteraction(i), Got: describe}
+	return r.err
+}
+
+type replayPrompter struct {
+	replayer *Replayer
+}
+
+func (p *replayPrompter) Confirm(q *prompt.Question) (prompt.Answer, error) {
+	i, err := p.replayer.next(KindPrompt, "prompt "+q.Text)
+	if err != nil {
+		return prompt.No, err
+	}
+	if i.Error != "" {
+		return prompt.No, errors.New(i.Error)
+	}
+	switch {
+	case i.Edit:
+		return prompt.Edit, nil
+	case i.Answer:
+		return prompt.Yes, nil
+	default:
+		return prompt.No, nil
+	}
+}
+
+func (p *replayPrompter) Edit(command string) (string, error) {
+	i, err := p.replayer.next(KindEdit, "edit "+command)
+	if err != nil {
+		return "", err
+	}
+	if i.Error != "" {
+		return "", errors.New(i.Error)
+	}
+	return i.Edited, nil
+}
+
+type replayExecutor struct {
+	replayer *Replayer
+}
+
+func (e *replayExecutor) Run(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+	describe := "exec " + req.Command
+	i, err := e.replayer.next(KindExec, describe)
+	if err != nil {
+		return nil, err
+	}
+	if i.Exec.Command != req.Command {
+		return nil, e.replayer.mismatch(i, describe)
+	}
+	if i.Error != "" {
+		return i.Result, errors.New(i.Error)
+	}
+	return i.Result, nil
+}
+
+// IsLocal reports whether the recorded session ran on its own machine.
+func (e *replayExecutor) IsLocal() bool {
+	return e.replayer.Cassette.Local
+}
+
+type replayClient struct {
+	replayer *Replayer
+}
+
+func (c *replayClient) GetSuggestion(req *llm.Request) (*llm.Suggestion, error) {
+	i, err := c.replayer.next(KindLLM, "llm request for "+req.Command)
+	if err != nil {
+		return nil, err
+	}
+	if i.Error != "" {
+		return i.Suggestion, errors.New(i.Error)
+	}
+	return i.Suggestion, nil
+}
+
+func describeInteraction(i *Interaction) string {
+	switch i.Kind {
+	case KindExec:
+		return "exec " + i.Exec.Command
+	case KindLLM:
+		return "llm request for " + i.LLMRequest.Command
+	case KindPrompt:
+		return "prompt " + i.Question
+	case KindEdit:
+		return "edit " + i.Question
+	case KindHost:
+		return "host " + i.Call
+	default:
+		return string(i.Kind)
+	}
+}
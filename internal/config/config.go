@@ -4,6 +4,9 @@ import (
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type LLMProvider string
@@ -30,9 +33,96 @@ type Config struct {
 		Model    string `yaml:"model"`
 	} `yaml:"llm"`
 	Safety struct {
-		AutoExecute        bool `yaml:"auto_execute"`
-		RequireSudoConfirm bool `yaml:"require_sudo_confirm"`
+		AutoExecute        bool   `yaml:"auto_execute"`
+		RequireSudoConfirm bool   `yaml:"require_sudo_confirm"`
+		SandboxTrial       bool   `yaml:"sandbox_trial"`
+		SandboxRootfs      string `yaml:"sandbox_rootfs"`
 	} `yaml:"safety"`
+	Network struct {
+		HTTPProxy  string `yaml:"http_proxy"`
+		HTTPSProxy string `yaml:"https_proxy"`
+		NoProxy    string `yaml:"no_proxy"`
+		CABundle   string `yaml:"ca_bundle"`
+	} `yaml:"network"`
+	KB struct {
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"kb"`
+	Recipes struct {
+		Paths []string `yaml:"paths"`
+	} `yaml:"recipes"`
+	Retry  Retry `yaml:"retry"`
+	Prompt struct {
+		MaxRisk   string `yaml:"max_risk"`
+		AllowSudo bool   `yaml:"allow_sudo"`
+	} `yaml:"prompt"`
+	Fallback struct {
+		Order    []string `yaml:"order"`
+		Binaries []Binary `yaml:"binaries"`
+	} `yaml:"fallback"`
+	Ports struct {
+		StopOwner bool   `yaml:"stop_owner"`
+		RerunWith string `yaml:"rerun_with"`
+	} `yaml:"ports"`
+	Log struct {
+		File string `yaml:"file"`
+	} `yaml:"log"`
+	History struct {
+		Enabled    bool `yaml:"enabled"`
+		MaxAgeDays int  `yaml:"max_age_days"`
+		MaxSizeMB  int  `yaml:"max_size_mb"`
+	} `yaml:"history"`
+}
+
+type RetryPolicy struct {
+	MaxAttempts int           `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+	Backoff     time.Duration `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	Budget      time.Duration `yaml:"budget,omitempty" json:"budget,omitempty"`
+	LLMOnRetry  *bool         `yaml:"llm_on_retry,omitempty" json:"llm_on_retry,omitempty"`
+}
+
+type Retry struct {
+	RetryPolicy `yaml:",inline"`
+	Overrides   map[string]RetryPolicy `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+}
+
+// For returns the policy for an error type: the base policy with any
+// fields set in that type's override.
+func (r Retry) For(errorType string) RetryPolicy {
+	return r.RetryPolicy.merge(r.Overrides[errorType])
+}
+
+// Overlay applies p on top of the base policy and every override, so
+// values given on the command line win for all error types.
+func (r Retry) Overlay(p RetryPolicy) Retry {
+	result := Retry{RetryPolicy: r.RetryPolicy.merge(p), Overrides: map[string]RetryPolicy{}}
+	for errorType, override := range r.Overrides {
+		result.Overrides[errorType] = override.merge(p)
+	}
+	return result
+}
+
+func (p RetryPolicy) merge(over RetryPolicy) RetryPolicy {
+	if over.MaxAttempts != 0 {
+		p.MaxAttempts = over.MaxAttempts
+	}
+	if over.Backoff != 0 {
+		p.Backoff = over.Backoff
+	}
+	if over.Budget != 0 {
+		p.Budget = over.Budget
+	}
+	if over.LLMOnRetry != nil {
+		p.LLMOnRetry = over.LLMOnRetry
+	}
+	return p
+}
+
+type Binary struct {
+	Name     string `yaml:"name"`
+	Platform string `yaml:"platform,omitempty"`
+	URL      string `yaml:"url"`
+	SHA256   string `yaml:"sha256"`
+	Path     string `yaml:"path,omitempty"`
 }
 
 var (
@@ -53,6 +143,13 @@ func Init() error {
 	cfg.LLM.Endpoint = "https://api.openai.com/v1"
 	cfg.LLM.Model = "gpt-4"
 	cfg.Safety.RequireSudoConfirm = true
+	cfg.KB.Enabled = true
+	cfg.Ports.StopOwner = true
+	cfg.Retry.MaxAttempts = 3
+	cfg.Ports.RerunWith = "PORT"
+	cfg.History.Enabled = true
+	cfg.History.MaxAgeDays = 90
+	cfg.History.MaxSizeMB = 50
 
 	if _, err := os.Stat(configPath); err == nil {
 		data, err := os.ReadFile(configPath)
@@ -94,6 +191,16 @@ func Get() *Config {
 	return cfg
 }
 
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func Set(key, value string) error {
 	switch key {
 	case "llm.provider":
@@ -108,6 +215,65 @@ func Set(key, value string) error {
 		cfg.Safety.AutoExecute = (value == "true")
 	case "safety.require_sudo_confirm":
 		cfg.Safety.RequireSudoConfirm = (value == "true")
+	case "safety.sandbox_trial":
+		cfg.Safety.SandboxTrial = (value == "true")
+	case "safety.sandbox_rootfs":
+		cfg.Safety.SandboxRootfs = value
+	case "network.http_proxy":
+		cfg.Network.HTTPProxy = value
+	case "network.https_proxy":
+		cfg.Network.HTTPSProxy = value
+	case "network.no_proxy":
+		cfg.Network.NoProxy = value
+	case "network.ca_bundle":
+		cfg.Network.CABundle = value
+	case "kb.enabled":
+		cfg.KB.Enabled = (value == "true")
+	case "recipes.paths":
+		cfg.Recipes.Paths = splitList(value)
+	case "retry.max_attempts":
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		cfg.Retry.MaxAttempts = attempts
+	case "retry.backoff", "retry.budget":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if key == "retry.backoff" {
+			cfg.Retry.Backoff = d
+		} else {
+			cfg.Retry.Budget = d
+		}
+	case "retry.llm_on_retry":
+		enabled := value == "true"
+		cfg.Retry.LLMOnRetry = &enabled
+	case "prompt.max_risk":
+		cfg.Prompt.MaxRisk = value
+	case "prompt.allow_sudo":
+		cfg.Prompt.AllowSudo = (value == "true")
+	case "fallback.order":
+		cfg.Fallback.Order = splitList(value)
+	case "ports.stop_owner":
+		cfg.Ports.StopOwner = (value == "true")
+	case "ports.rerun_with":
+		cfg.Ports.RerunWith = value
+	case "log.file":
+		cfg.Log.File = value
+	case "history.enabled":
+		cfg.History.Enabled = (value == "true")
+	case "history.max_age_days", "history.max_size_mb":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if key == "history.max_age_days" {
+			cfg.History.MaxAgeDays = n
+		} else {
+			cfg.History.MaxSizeMB = n
+		}
 	}
 	return Save()
 }
@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/autofix/cli/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var retry config.Retry
+	err := yaml.Unmarshal([]byte(`
+max_attempts: 3
+backoff: 1s
+overrides:
+  network:
+    max_attempts: 5
+    backoff: 10s
+    budget: 2m
+  permission_denied:
+    llm_on_retry: true
+`), &retry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yes := true
+	faster := config.RetryPolicy{Backoff: 100 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		retry     config.Retry
+		errorType string
+		want      config.RetryPolicy
+	}{
+		{"base", retry, "missing_command", config.RetryPolicy{MaxAttempts: 3, Backoff: time.Second}},
+		{"override", retry, "network", config.RetryPolicy{MaxAttempts: 5, Backoff: 10 * time.Second, Budget: 2 * time.Minute}},
+		{"partial override", retry, "permission_denied", config.RetryPolicy{MaxAttempts: 3, Backoff: time.Second, LLMOnRetry: &yes}},
+		{"overlay on base", retry.Overlay(faster), "missing_command", config.RetryPolicy{MaxAttempts: 3, Backoff: 100 * time.Millisecond}},
+		{"overlay wins over override", retry.Overlay(faster), "network", config.RetryPolicy{MaxAttempts: 5, Backoff: 100 * time.Millisecond, Budget: 2 * time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.retry.For(tt.errorType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("For(%s) = %+v, want %+v", tt.errorType, got, tt.want)
+			}
+		})
+	}
+}
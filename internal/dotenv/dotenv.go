@@ -0,0 +1,137 @@
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func Parse(r io.Reader) ([]string, error) {
+	vars := []string{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: empty key", lineNum)
+		}
+		vars = append(vars, key+"="+unquote(strings.TrimSpace(value)))
+	}
+	return vars, scanner.Err()
+}
+
+func Load(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+func Set(path, key, value string) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	entry := key + "=" + quote(value)
+	lines := []string{}
+	if len(content) > 0 {
+		lines = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	}
+
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(line), "export ")
+		if name, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(name) == key {
+			lines[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, entry)
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
+func Lookup(path, key string) (string, bool) {
+	vars, err := Load(path)
+	if err != nil {
+		return "", false
+	}
+	for i := len(vars) - 1; i >= 0; i-- {
+		if name, value, _ := strings.Cut(vars[i], "="); name == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func Unset(path, key string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	kept := []string{}
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(line), "export ")
+		if name, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(name) == key {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	if len(kept) == 0 {
+		return os.WriteFile(path, nil, 0600)
+	}
+	return os.WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"), 0600)
+}
+
+// unquote strips the quotes around a value. Inside double quotes, \" and
+// \\ stand for a quote and a backslash; other backslashes are literal.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if first == '\'' && last == first {
+			return value[1 : len(value)-1]
+		}
+		if first == '"' && last == first {
+			return doubleQuoted.Replace(value[1 : len(value)-1])
+		}
+	}
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = strings.TrimSpace(value[:idx])
+	}
+	return value
+}
+
+var doubleQuoted = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+func quote(value string) string {
+	if !strings.ContainsAny(value, " \t#'\"") {
+		return value
+	}
+	if strings.Contains(value, `"`) && !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
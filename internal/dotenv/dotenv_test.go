@@ -0,0 +1,91 @@
+package dotenv_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/autofix/cli/internal/dotenv"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "plain", content: "A=1\nB = two\n", want: []string{"A=1", "B=two"}},
+		{name: "comments and export", content: "# comment\n\nexport A=1\n", want: []string{"A=1"}},
+		{name: "inline comment", content: "A=1 # one\n", want: []string{"A=1"}},
+		{name: "single quotes are literal", content: `A='x \" # y'`, want: []string{`A=x \" # y`}},
+		{name: "double quotes", content: `A="x # y"`, want: []string{"A=x # y"}},
+		{name: "escapes in double quotes", content: `A="it's \"quoted\" \\ C:\path"`, want: []string{`A=it's "quoted" \ C:\path`}},
+		{name: "empty", content: `A=""`, want: []string{"A="}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dotenv.Parse(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, content := range []string{"NOVALUE\n", "=1\n"} {
+		if _, err := dotenv.Parse(strings.NewReader(content)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", content)
+		}
+	}
+}
+
+func TestSetRoundTrip(t *testing.T) {
+	values := []string{
+		"plain",
+		"",
+		"with space",
+		"hash # sign",
+		`say "hi"`,
+		"it's",
+		`it's "both"`,
+		`back\slash and space`,
+		`trailing backslash \`,
+		`\"`,
+		"postgres://user:p@ss@localhost:5432/db?sslmode=disable",
+	}
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := dotenv.Set(path, "KEY", value); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := dotenv.Lookup(path, "KEY")
+			if !ok || got != value {
+				t.Errorf("Lookup = %q, %v, want %q", got, ok, value)
+			}
+		})
+	}
+}
+
+func TestSetReplacesAndUnsetRemoves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	for _, kv := range [][2]string{{"A", "1"}, {"B", "2"}, {"A", "3"}} {
+		if err := dotenv.Set(path, kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if vars, _ := dotenv.Load(path); !reflect.DeepEqual(vars, []string{"A=3", "B=2"}) {
+		t.Errorf("after Set: %q", vars)
+	}
+	if err := dotenv.Unset(path, "A"); err != nil {
+		t.Fatal(err)
+	}
+	if vars, _ := dotenv.Load(path); !reflect.DeepEqual(vars, []string{"B=2"}) {
+		t.Errorf("after Unset: %q", vars)
+	}
+}
@@ -0,0 +1,126 @@
+package ensure_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/autofix/cli/internal/ensure"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/executor"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+		err  bool
+	}{
+		{spec: "make", want: "make"},
+		{spec: "node >= 18", want: "node >= 18"},
+		{spec: "python3>3.9", want: "python3 > 3.9"},
+		{spec: "go == v1.21", want: "go = 1.21"},
+		{spec: "libssl-dev", want: "libssl-dev"},
+		{spec: "node ~ 18", err: true},
+		{spec: ">= 18", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			r, err := ensure.Parse(tt.spec)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parsed %q as %s, want an error", tt.spec, r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.10", "3.9", 1},
+		{"18", "18.0.0", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"20.11.1", "18", 1},
+	}
+	for _, tt := range tests {
+		if got := ensure.Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{"make", "", true},
+		{"node >= 18", "20.11.1", true},
+		{"node >= 18", "16.20.0", false},
+		{"node >= 18", "", false},
+		{"node = 18", "18.19.0", true},
+		{"node <= 18", "18.19.0", true},
+		{"node > 18", "18.19.0", false},
+		{"node > 18", "19.0.0", true},
+		{"python3 < 3.12", "3.11.4", true},
+		{"python3 >= 3.10", "3.9.18", false},
+	}
+	for _, tt := range tests {
+		r, err := ensure.Parse(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Accepts(tt.version); got != tt.want {
+			t.Errorf("%s accepts %q = %v, want %v", tt.spec, tt.version, got, tt.want)
+		}
+	}
+}
+
+// query answers the package manager's query with a fixed output.
+type query struct {
+	stdout string
+}
+
+func (q query) Run(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+	return &executor.Result{Command: req.Command, Success: true, Stdout: q.stdout}, nil
+}
+
+func TestCheckPackageVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		pm     env.PackageManager
+		stdout string
+		want   string
+	}{
+		{name: "dpkg", pm: env.PMApt, stdout: "Package: libssl-dev\nStatus: install ok installed\nVersion: 3.0.11-1~deb12u2\n", want: "3.0.11"},
+		{name: "dpkg with epoch", pm: env.PMApt, stdout: "Package: libssl-dev\nVersion: 1:9.18.1-1\n", want: "9.18.1"},
+		{name: "pacman with epoch", pm: env.PMPacman, stdout: "openssl 1:3.2.1-1\n", want: "3.2.1"},
+		{name: "rpm", pm: env.PMDnf, stdout: "openssl-devel-3.0.9-2.fc39.x86_64\n", want: "3.0.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ensure.Requirement{Name: "libssl-dev", Op: ">=", Version: "3"}
+			if tt.pm != env.PMApt {
+				r.Package = "openssl"
+				if tt.pm == env.PMDnf {
+					r.Package = "openssl-devel"
+				}
+			}
+			status := ensure.Check(query{tt.stdout}, &env.Environment{PackageManager: tt.pm}, r)
+			if !status.Installed || status.Version != tt.want {
+				t.Errorf("installed %v, version %q, want %q", status.Installed, status.Version, tt.want)
+			}
+		})
+	}
+}
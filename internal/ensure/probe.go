@@ -0,0 +1,126 @@
+package ensure
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/executor"
+)
+
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+|\d+`)
+
+// epochPattern matches the epoch that dpkg and pacman put before a
+// package version, as in 1:9.18.1-1.
+var epochPattern = regexp.MustCompile(`^\s*\d+:`)
+
+// versionArgs are the arguments that make a tool print its version, for
+// tools that do not take --version.
+var versionArgs = map[string]string{"go": "version", "java": "-version", "javac": "-version"}
+
+// runtimeNames maps requirement names to the runtimes env.Detect reports.
+var runtimeNames = map[string]string{"node": "node", "nodejs": "node", "python3": "python", "docker": "docker"}
+
+// Status is what was found for a requirement.
+type Status struct {
+	Requirement *Requirement `json:"requirement"`
+	Package     bool         `json:"package"`
+	Installed   bool         `json:"installed"`
+	Version     string       `json:"version,omitempty"`
+	OK          bool         `json:"ok"`
+}
+
+func (s *Status) String() string {
+	switch {
+	case s.OK && s.Version != "":
+		return "ok (" + s.Version + ")"
+	case s.OK:
+		return "ok"
+	case !s.Installed:
+		return "missing"
+	case s.Version == "":
+		return "installed, version unknown"
+	default:
+		return "outdated (" + s.Version + ")"
+	}
+}
+
+// IsPackage reports whether r names a library package rather than a
+// command, e.g. libpq-dev.
+func IsPackage(r *Requirement) bool {
+	for _, suffix := range []string{"-dev", "-devel", "-headers"} {
+		if strings.HasSuffix(r.Name, suffix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(r.Name, "lib")
+}
+
+// Check probes for r using the detected runtimes, the package manager for
+// packages, and the tool's own version output otherwise.
+func Check(ex executor.Executor, environment *env.Environment, r *Requirement) *Status {
+	status := &Status{Requirement: r, Package: IsPackage(r)}
+	if status.Package {
+		checkPackage(ex, environment.PackageManager, status)
+	} else {
+		checkCommand(ex, environment, status)
+	}
+	status.OK = status.Installed && r.Accepts(status.Version)
+	return status
+}
+
+func checkPackage(ex executor.Executor, pm env.PackageManager, status *Status) {
+	pkg := PackageName(status.Requirement, pm)
+	query := pm.QueryCommand(pkg)
+	if query == "" {
+		return
+	}
+	result, err := ex.Run(context.Background(), &executor.Request{Command: query})
+	if err != nil || !result.Success {
+		return
+	}
+	status.Installed = true
+	for _, line := range strings.Split(result.Stdout, "\n") {
+		// dpkg -s prints a Version: field; the others print the
+		// version after the package name.
+		if value, ok := strings.CutPrefix(line, "Version:"); ok {
+			status.Version = packageVersion(value)
+			return
+		}
+	}
+	status.Version = packageVersion(strings.TrimPrefix(strings.TrimSpace(result.Stdout), pkg))
+}
+
+func packageVersion(s string) string {
+	return versionPattern.FindString(epochPattern.ReplaceAllString(s, ""))
+}
+
+func checkCommand(ex executor.Executor, environment *env.Environment, status *Status) {
+	name := status.Requirement.Name
+	for _, rt := range environment.Runtimes {
+		if rt.Name == runtimeNames[name] {
+			status.Installed, status.Version = true, versionPattern.FindString(rt.Version)
+			return
+		}
+	}
+
+	args := versionArgs[name]
+	if args == "" {
+		args = "--version"
+	}
+	result, err := ex.Run(context.Background(), &executor.Request{Command: name + " " + args})
+	if err != nil || missing(result) {
+		return
+	}
+	status.Installed = true
+	if result.Success {
+		status.Version = versionPattern.FindString(result.Stdout + result.Stderr)
+	}
+}
+
+func missing(result *executor.Result) bool {
+	return result.ExitCode == 127 ||
+		strings.Contains(result.Stderr, "executable file not found") ||
+		strings.Contains(result.Stderr, "no such file or directory")
+}
@@ -0,0 +1,149 @@
+package ensure
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var specPattern = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._+-]*)\s*(?:(>=|<=|==|=|>|<)\s*v?([0-9][0-9A-Za-z.]*))?\s*$`)
+
+// Requirement is something a project needs, such as "node >= 18".
+type Requirement struct {
+	Name    string `yaml:"name" json:"name"`
+	Op      string `yaml:"-" json:"op,omitempty"`
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+	// Via forces a strategy: package, nvm, pyenv or asdf.
+	Via string `yaml:"via,omitempty" json:"via,omitempty"`
+	// Package overrides the package name used by the package manager.
+	Package string `yaml:"package,omitempty" json:"package,omitempty"`
+}
+
+// Parse reads "name", or "name OP version" with OP one of >=, >, <=, <
+// and =.
+func Parse(spec string) (*Requirement, error) {
+	m := specPattern.FindStringSubmatch(spec)
+	if m == nil {
+		return nil, fmt.Errorf("invalid requirement %q (want e.g. \"node >= 18\" or \"make\")", spec)
+	}
+	r := &Requirement{Name: m[1], Op: m[2], Version: m[3]}
+	if r.Op == "==" {
+		r.Op = "="
+	}
+	return r, nil
+}
+
+// UnmarshalYAML accepts either the short string form or a mapping with
+// name, version (which may carry an operator), via and package.
+func (r *Requirement) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		parsed, err := Parse(node.Value)
+		if err != nil {
+			return err
+		}
+		*r = *parsed
+		return nil
+	}
+
+	type plain Requirement
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	spec := p.Name
+	if p.Version != "" {
+		spec += " " + p.Version
+		if !strings.ContainsAny(p.Version, "<>=") {
+			spec = p.Name + " >= " + p.Version
+		}
+	}
+	parsed, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	switch p.Via {
+	case "", ViaPackage, ViaNvm, ViaPyenv, ViaAsdf:
+	default:
+		return fmt.Errorf("requirement %s: unknown strategy %q (want package, nvm, pyenv or asdf)", p.Name, p.Via)
+	}
+	*r = *parsed
+	r.Via, r.Package = p.Via, p.Package
+	return nil
+}
+
+func (r *Requirement) String() string {
+	if r.Op == "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s %s %s", r.Name, r.Op, r.Version)
+}
+
+// Accepts reports whether an installed version meets the constraint.
+func (r *Requirement) Accepts(version string) bool {
+	if r.Op == "" {
+		return true
+	}
+	if version == "" {
+		return false
+	}
+	// Versions compare at the constraint's precision, so "= 18" and
+	// "<= 18" accept any 18.x and "> 18" needs 19 or later.
+	c := Compare(truncate(version, r.Version), r.Version)
+	switch r.Op {
+	case ">=":
+		return c >= 0
+	case ">":
+		return c > 0
+	case "<=":
+		return c <= 0
+	case "<":
+		return c < 0
+	default:
+		return c == 0
+	}
+}
+
+// Compare orders dotted versions numerically, treating missing parts as
+// zero, so "3.10" > "3.9" and "18" == "18.0.0".
+func Compare(a, b string) int {
+	as, bs := numbers(a), numbers(b)
+	for len(as) < len(bs) {
+		as = append(as, 0)
+	}
+	for len(bs) < len(as) {
+		bs = append(bs, 0)
+	}
+	for i := range as {
+		switch {
+		case as[i] < bs[i]:
+			return -1
+		case as[i] > bs[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func numbers(version string) []int {
+	var parts []int
+	for _, field := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		if end := strings.IndexFunc(field, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			field = field[:end]
+		}
+		n, _ := strconv.Atoi(field)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// truncate cuts version to as many parts as like has.
+func truncate(version, like string) string {
+	parts := strings.Split(version, ".")
+	if n := len(strings.Split(like, ".")); len(parts) > n {
+		parts = parts[:n]
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,189 @@
+package ensure
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/fallback"
+)
+
+const (
+	ViaPackage = "package"
+	ViaNvm     = "nvm"
+	ViaPyenv   = "pyenv"
+	ViaAsdf    = "asdf"
+)
+
+// managers lists, per tool, the version managers that can install it in
+// order of preference.
+var managers = map[string][]string{
+	"node":    {ViaNvm, ViaAsdf},
+	"nodejs":  {ViaNvm, ViaAsdf},
+	"python":  {ViaPyenv, ViaAsdf},
+	"python3": {ViaPyenv, ViaAsdf},
+}
+
+var asdfPlugins = map[string]string{"node": "nodejs", "python3": "python", "go": "golang"}
+
+var packageNames = map[string]map[env.PackageManager]string{
+	"node":      {env.PMApt: "nodejs", env.PMDnf: "nodejs", env.PMYum: "nodejs", env.PMPacman: "nodejs", env.PMBrew: "node"},
+	"python3":   {env.PMPacman: "python", env.PMBrew: "python3"},
+	"docker":    {env.PMApt: "docker.io", env.PMDnf: "moby-engine", env.PMYum: "docker"},
+	"go":        {env.PMApt: "golang-go", env.PMDnf: "golang", env.PMYum: "golang"},
+	"java":      {env.PMApt: "default-jdk", env.PMDnf: "java-latest-openjdk-devel", env.PMYum: "java-latest-openjdk-devel", env.PMPacman: "jdk-openjdk", env.PMBrew: "openjdk"},
+	"libpq-dev": {env.PMDnf: "libpq-devel", env.PMYum: "libpq-devel", env.PMPacman: "postgresql-libs", env.PMBrew: "libpq"},
+}
+
+// Action is one command of an install, with the command undoing it.
+type Action struct {
+	Command  string
+	Rollback string
+}
+
+// PackageName is the name pm knows r by. Debian-style -dev packages are
+// -devel on Fedora and have no separate headers package on Arch or brew.
+func PackageName(r *Requirement, pm env.PackageManager) string {
+	if r.Package != "" {
+		return r.Package
+	}
+	if name := packageNames[r.Name][pm]; name != "" {
+		return name
+	}
+	if base, ok := strings.CutSuffix(r.Name, "-dev"); ok {
+		switch pm {
+		case env.PMDnf, env.PMYum:
+			return base + "-devel"
+		case env.PMPacman, env.PMBrew:
+			return base
+		}
+	}
+	return r.Name
+}
+
+// Strategy picks how to install r: the one it names, else the first
+// version manager for the tool that is set up here, else the package
+// manager.
+func Strategy(r *Requirement) string {
+	if r.Via != "" {
+		return r.Via
+	}
+	for _, manager := range managers[r.Name] {
+		if available(manager) {
+			return manager
+		}
+	}
+	return ViaPackage
+}
+
+func available(manager string) bool {
+	if manager == ViaNvm {
+		_, err := os.Stat(nvmScript())
+		return err == nil
+	}
+	_, err := exec.LookPath(manager)
+	return err == nil
+}
+
+// Actions returns the commands that install or upgrade r with strategy.
+// Package commands keep their sudo prefix; the caller adapts them to the
+// user it runs as.
+func Actions(r *Requirement, strategy string, pm env.PackageManager, installed bool) ([]Action, error) {
+	version, err := target(r, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strategy {
+	case ViaPackage:
+		pkg := PackageName(r, pm)
+		if installed {
+			// An upgrade cannot be rolled back to the old version.
+			upgrade := pm.UpgradeCommand(pkg)
+			if upgrade == "" {
+				return nil, fmt.Errorf("no package manager to upgrade %s with", pkg)
+			}
+			return []Action{{Command: upgrade}}, nil
+		}
+		install := pm.InstallCommand(pkg)
+		if install == "" {
+			return nil, fmt.Errorf("no package manager to install %s with", pkg)
+		}
+		return []Action{{Command: install, Rollback: pm.RemoveCommand(pkg)}}, nil
+	case ViaNvm:
+		nvm, err := nvmShim()
+		if err != nil {
+			return nil, err
+		}
+		return []Action{
+			{Command: fmt.Sprintf("%s install %s", nvm, version), Rollback: fmt.Sprintf("%s uninstall %s", nvm, version)},
+			{Command: fmt.Sprintf("%s alias default %s", nvm, version)},
+		}, nil
+	case ViaPyenv:
+		return []Action{
+			{Command: "pyenv install -s " + version, Rollback: "pyenv uninstall -f " + version},
+			{Command: "pyenv global " + version},
+		}, nil
+	case ViaAsdf:
+		plugin := asdfPlugins[r.Name]
+		if plugin == "" {
+			plugin = r.Name
+		}
+		return []Action{
+			{Command: fmt.Sprintf("asdf install %s %s", plugin, version), Rollback: fmt.Sprintf("asdf uninstall %s %s", plugin, version)},
+			{Command: fmt.Sprintf("asdf global %s %s", plugin, version)},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown strategy %q", strategy)
+}
+
+// target is the version to ask a version manager for: the newest release
+// of the required line, or the latest (LTS for node) without a version.
+func target(r *Requirement, strategy string) (string, error) {
+	if strategy == ViaPackage {
+		return "", nil
+	}
+	switch r.Op {
+	case "":
+		switch strategy {
+		case ViaNvm:
+			return "--lts", nil
+		case ViaAsdf:
+			return "latest", nil
+		}
+		return "3", nil
+	case "<", ">":
+		return "", fmt.Errorf("%s: %s cannot pick a version for %s; use >=, <= or =", r, strategy, r.Op)
+	}
+	if strategy == ViaAsdf {
+		return "latest:" + r.Version, nil
+	}
+	return r.Version, nil
+}
+
+func nvmScript() string {
+	dir := os.Getenv("NVM_DIR")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".nvm")
+	}
+	return filepath.Join(dir, "nvm.sh")
+}
+
+// nvmShim writes a small script that runs nvm, which is a shell function,
+// as a command.
+func nvmShim() (string, error) {
+	dir, err := fallback.BinDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "autofix-nvm")
+	script := fmt.Sprintf("#!/usr/bin/env bash\n. '%s' --no-use\nnvm \"$@\"\n", strings.ReplaceAll(nvmScript(), "'", `'\''`))
+	return path, os.WriteFile(path, []byte(script), 0755)
+}
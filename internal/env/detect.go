@@ -1,69 +1,88 @@
 package env
 
 import (
+	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+
+	"github.com/autofix/cli/internal/executor"
 )
 
+type probe interface {
+	goos() string
+	goarch() string
+	readFile(path string) (string, error)
+	exists(path string) bool
+	lookPath(name string) bool
+	output(name string, args ...string) (string, error)
+}
+
 func Detect() *Environment {
+	return detect(localProbe{})
+}
+
+func DetectRemote(ex executor.Executor) *Environment {
+	return detect(&remoteProbe{executor: ex})
+}
+
+func detect(p probe) *Environment {
 	env := &Environment{
-		OS:           detectOS(),
-		OSVersion:    detectOSVersion(),
-		Architecture: detectArchitecture(),
-	}
-	env.PackageManager = detectPackageManager(env.OS)
-	env.HasSudo = detectSudo()
-	env.InContainer = detectContainer()
-	env.Runtimes = detectRuntimes()
+		OS:           detectOS(p),
+		OSVersion:    detectOSVersion(p),
+		Architecture: detectArchitecture(p),
+	}
+	env.PackageManager = detectPackageManager(p, env.OS)
+	env.HasSudo = detectSudo(p)
+	env.IsRoot = detectRoot(p)
+	env.InContainer = detectContainer(p)
+	env.Runtimes = detectRuntimes(p)
 	return env
 }
 
-func detectOS() OS {
-	if runtime.GOOS == "darwin" {
+func detectOS(p probe) OS {
+	if p.goos() == "darwin" {
 		return OSMacOS
 	}
 
 	for _, path := range []string{"/etc/os-release", "/etc/lsb-release"} {
-		content, err := os.ReadFile(path)
+		content, err := p.readFile(path)
 		if err == nil {
-			contentStr := string(content)
-			if strings.Contains(contentStr, "Ubuntu") {
+			if strings.Contains(content, "Ubuntu") {
 				return OSUbuntu
 			}
-			if strings.Contains(contentStr, "Debian") {
+			if strings.Contains(content, "Debian") {
 				return OSDebian
 			}
-			if strings.Contains(contentStr, "Fedora") {
+			if strings.Contains(content, "Fedora") {
 				return OSFedora
 			}
 		}
 	}
 
-	if _, err := os.Stat("/etc/arch-release"); err == nil {
+	if p.exists("/etc/arch-release") {
 		return OSArch
 	}
 
-	if _, err := os.Stat("/etc/fedora-release"); err == nil {
+	if p.exists("/etc/fedora-release") {
 		return OSFedora
 	}
 
 	return OSUnknown
 }
 
-func detectOSVersion() string {
-	if runtime.GOOS == "darwin" {
-		cmd := exec.Command("sw_vers", "-productVersion")
-		if output, err := cmd.Output(); err == nil {
-			return strings.TrimSpace(string(output))
+func detectOSVersion(p probe) string {
+	if p.goos() == "darwin" {
+		if output, err := p.output("sw_vers", "-productVersion"); err == nil {
+			return strings.TrimSpace(output)
 		}
 	}
 
-	content, err := os.ReadFile("/etc/os-release")
+	content, err := p.readFile("/etc/os-release")
 	if err == nil {
-		contentStr := string(content)
-		for _, line := range strings.Split(contentStr, "\n") {
+		for _, line := range strings.Split(content, "\n") {
 			if strings.HasPrefix(line, "VERSION=") {
 				return strings.Trim(strings.TrimPrefix(line, "VERSION="), `"`)
 			}
@@ -73,8 +92,8 @@ func detectOSVersion() string {
 	return "unknown"
 }
 
-func detectArchitecture() Architecture {
-	arch := runtime.GOARCH
+func detectArchitecture(p probe) Architecture {
+	arch := p.goarch()
 	if arch == "amd64" {
 		return ArchAMD64
 	}
@@ -84,91 +103,166 @@ func detectArchitecture() Architecture {
 	return ArchUnknown
 }
 
-func detectPackageManager(os OS) PackageManager {
+func detectPackageManager(p probe, os OS) PackageManager {
 	switch os {
 	case OSMacOS:
-		if _, err := exec.LookPath("brew"); err == nil {
+		if p.lookPath("brew") {
 			return PMBrew
 		}
 	case OSUbuntu, OSDebian:
-		if _, err := exec.LookPath("apt"); err == nil {
+		if p.lookPath("apt") {
 			return PMApt
 		}
 	case OSFedora:
-		if _, err := exec.LookPath("dnf"); err == nil {
+		if p.lookPath("dnf") {
 			return PMDnf
 		}
-		if _, err := exec.LookPath("yum"); err == nil {
+		if p.lookPath("yum") {
 			return PMYum
 		}
 	case OSArch:
-		if _, err := exec.LookPath("pacman"); err == nil {
+		if p.lookPath("pacman") {
 			return PMPacman
 		}
 	}
 	return PMNone
 }
 
-func detectSudo() bool {
-	_, err := exec.LookPath("sudo")
-	return err == nil
+func detectSudo(p probe) bool {
+	return p.lookPath("sudo")
 }
 
-func detectContainer() bool {
-	if _, err := os.Stat("/.dockerenv"); err == nil {
+func detectRoot(p probe) bool {
+	uid, err := p.output("id", "-u")
+	return err == nil && strings.TrimSpace(uid) == "0"
+}
+
+func detectContainer(p probe) bool {
+	if p.exists("/.dockerenv") {
 		return true
 	}
-	content, err := os.ReadFile("/proc/1/cgroup")
+	content, err := p.readFile("/proc/1/cgroup")
 	if err == nil {
-		contentStr := string(content)
-		if strings.Contains(contentStr, "docker") || strings.Contains(contentStr, "lxc") {
+		if strings.Contains(content, "docker") || strings.Contains(content, "lxc") {
 			return true
 		}
 	}
 	return false
 }
 
-func detectRuntimes() []Runtime {
+func detectRuntimes(p probe) []Runtime {
 	runtimes := []Runtime{}
 
-	if version, err := detectNodeVersion(); err == nil {
-		runtimes = append(runtimes, Runtime{Name: "node", Version: version})
+	if version, err := p.output("node", "--version"); err == nil {
+		runtimes = append(runtimes, Runtime{Name: "node", Version: strings.TrimSpace(version)})
 	}
 
-	if version, err := detectPythonVersion(); err == nil {
-		runtimes = append(runtimes, Runtime{Name: "python", Version: version})
+	if version, err := p.output("python3", "--version"); err == nil {
+		runtimes = append(runtimes, Runtime{Name: "python", Version: strings.TrimSpace(version)})
 	}
 
-	if version, err := detectDockerVersion(); err == nil {
-		runtimes = append(runtimes, Runtime{Name: "docker", Version: version})
+	if version, err := p.output("docker", "--version"); err == nil {
+		runtimes = append(runtimes, Runtime{Name: "docker", Version: strings.TrimSpace(version)})
 	}
 
 	return runtimes
 }
 
-func detectNodeVersion() (string, error) {
-	cmd := exec.Command("node", "--version")
-	output, err := cmd.Output()
+type localProbe struct{}
+
+func (localProbe) goos() string {
+	return runtime.GOOS
+}
+
+func (localProbe) goarch() string {
+	return runtime.GOARCH
+}
+
+func (localProbe) readFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	return string(content), err
+}
+
+func (localProbe) exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (localProbe) lookPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func (localProbe) output(name string, args ...string) (string, error) {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	return string(output), err
+}
+
+type remoteProbe struct {
+	executor executor.Executor
+	uname    []string
+}
+
+func (r *remoteProbe) run(command string) (*executor.Result, error) {
+	result, err := r.executor.Run(context.Background(), &executor.Request{Command: command})
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if !result.Success {
+		return result, errors.New(strings.TrimSpace(result.Stderr))
+	}
+	return result, nil
+}
+
+func (r *remoteProbe) unameFields() []string {
+	if r.uname == nil {
+		r.uname = []string{"", ""}
+		if result, err := r.run("uname -sm"); err == nil {
+			if fields := strings.Fields(result.Stdout); len(fields) == 2 {
+				r.uname = fields
+			}
+		}
 	}
-	return strings.TrimSpace(string(output)), nil
+	return r.uname
 }
 
-func detectPythonVersion() (string, error) {
-	cmd := exec.Command("python3", "--version")
-	output, err := cmd.CombinedOutput()
+func (r *remoteProbe) goos() string {
+	return strings.ToLower(r.unameFields()[0])
+}
+
+func (r *remoteProbe) goarch() string {
+	switch r.unameFields()[1] {
+	case "x86_64", "amd64":
+		return "amd64"
+	case "aarch64", "arm64":
+		return "arm64"
+	default:
+		return r.unameFields()[1]
+	}
+}
+
+func (r *remoteProbe) readFile(path string) (string, error) {
+	result, err := r.run("cat " + executor.ShellQuote(path))
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(output)), nil
+	return result.Stdout, nil
+}
+
+func (r *remoteProbe) exists(path string) bool {
+	_, err := r.run("test -e " + executor.ShellQuote(path))
+	return err == nil
+}
+
+func (r *remoteProbe) lookPath(name string) bool {
+	_, err := r.run("command -v " + name)
+	return err == nil
 }
 
-func detectDockerVersion() (string, error) {
-	cmd := exec.Command("docker", "--version")
-	output, err := cmd.Output()
+func (r *remoteProbe) output(name string, args ...string) (string, error) {
+	result, err := r.run(strings.Join(append([]string{name}, args...), " "))
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(output)), nil
+	return result.Stdout + result.Stderr, nil
 }
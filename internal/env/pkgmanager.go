@@ -0,0 +1,182 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (pm PackageManager) InstallCommand(pkgs ...string) string {
+	if len(pkgs) == 0 {
+		return ""
+	}
+	list := strings.Join(pkgs, " ")
+
+	switch pm {
+	case PMApt:
+		return fmt.Sprintf("sudo apt-get install -y %s", list)
+	case PMDnf, PMYum:
+		return fmt.Sprintf("sudo dnf install -y %s", list)
+	case PMPacman:
+		return fmt.Sprintf("sudo pacman -S --noconfirm %s", list)
+	case PMBrew:
+		return fmt.Sprintf("brew install %s", list)
+	default:
+		return ""
+	}
+}
+
+func (pm PackageManager) RemoveCommand(pkgs ...string) string {
+	if len(pkgs) == 0 {
+		return ""
+	}
+	list := strings.Join(pkgs, " ")
+
+	switch pm {
+	case PMApt:
+		return fmt.Sprintf("sudo apt-get remove -y %s", list)
+	case PMDnf, PMYum:
+		return fmt.Sprintf("sudo dnf remove -y %s", list)
+	case PMPacman:
+		return fmt.Sprintf("sudo pacman -R --noconfirm %s", list)
+	case PMBrew:
+		return fmt.Sprintf("brew uninstall %s", list)
+	default:
+		return ""
+	}
+}
+
+func (pm PackageManager) QueryCommand(pkg string) string {
+	switch pm {
+	case PMApt:
+		return "dpkg -s " + pkg
+	case PMDnf, PMYum:
+		return "rpm -q " + pkg
+	case PMPacman:
+		return "pacman -Q " + pkg
+	case PMBrew:
+		return "brew list --versions " + pkg
+	default:
+		return ""
+	}
+}
+
+func (pm PackageManager) ReinstallCommand(pkg string) string {
+	switch pm {
+	case PMApt:
+		return "sudo apt-get install --reinstall -y " + pkg
+	case PMDnf, PMYum:
+		return "sudo dnf reinstall -y " + pkg
+	case PMPacman:
+		return "sudo pacman -S --noconfirm " + pkg
+	case PMBrew:
+		return "brew reinstall " + pkg
+	default:
+		return ""
+	}
+}
+
+// UpgradeCommand upgrades an installed package to the newest version in
+// the package manager's repositories.
+func (pm PackageManager) UpgradeCommand(pkg string) string {
+	switch pm {
+	case PMApt:
+		return "sudo apt-get install --only-upgrade -y " + pkg
+	case PMDnf, PMYum:
+		return "sudo dnf upgrade -y " + pkg
+	case PMPacman:
+		return "sudo pacman -S --noconfirm " + pkg
+	case PMBrew:
+		return "brew upgrade " + pkg
+	default:
+		return ""
+	}
+}
+
+// InstalledPackages returns the packages that output shows were newly
+// installed, leaving out packages that were upgraded or reinstalled.
+func (pm PackageManager) InstalledPackages(output string) []string {
+	pkgs := []string{}
+	seen := map[string]bool{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			pkgs = append(pkgs, name)
+		}
+	}
+
+	// apt lists new packages, dependencies included, in an indented block
+	// under a heading; apt 3 splits them across two headings.
+	inNew := false
+	// brew pours bottles for upgrades and reinstalls too.
+	replaced := map[string]bool{}
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimSpace(raw)
+		switch pm {
+		case PMApt:
+			if raw != line && line != "" {
+				if inNew {
+					for _, field := range strings.Fields(line) {
+						name, _, _ := strings.Cut(field, ":")
+						add(name)
+					}
+				}
+				continue
+			}
+			switch line {
+			case "The following NEW packages will be installed:", "Installing:", "Installing dependencies:":
+				inNew = true
+			default:
+				inNew = false
+			}
+		case PMDnf, PMYum:
+			if strings.HasPrefix(line, "Installing") && strings.Contains(line, ":") {
+				_, nevra, _ := strings.Cut(line, ":")
+				add(rpmName(strings.Fields(nevra)))
+			}
+		case PMPacman:
+			// (1/2) installing sl, as opposed to reinstalling or upgrading.
+			if fields := strings.Fields(line); len(fields) > 2 && strings.HasPrefix(fields[0], "(") && fields[1] == "installing" {
+				add(strings.TrimSuffix(fields[2], "..."))
+			}
+		case PMBrew:
+			for _, prefix := range []string{"==> Upgrading ", "==> Reinstalling "} {
+				if name, ok := strings.CutPrefix(line, prefix); ok {
+					replaced[strings.TrimSpace(name)] = true
+				}
+			}
+			if strings.HasPrefix(line, "==> Pouring ") {
+				name, _, _ := strings.Cut(strings.TrimPrefix(line, "==> Pouring "), "--")
+				if !replaced[name] {
+					add(name)
+				}
+			}
+		}
+	}
+	return pkgs
+}
+
+func rpmName(fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	parts := strings.Split(fields[0], "-")
+	if len(parts) < 3 {
+		return fields[0]
+	}
+	return strings.Join(parts[:len(parts)-2], "-")
+}
+
+// DefaultPackageManager is the usual package manager for an OS.
+func DefaultPackageManager(os OS) PackageManager {
+	switch os {
+	case OSUbuntu, OSDebian:
+		return PMApt
+	case OSFedora:
+		return PMDnf
+	case OSArch:
+		return PMPacman
+	case OSMacOS:
+		return PMBrew
+	}
+	return PMNone
+}
@@ -0,0 +1,93 @@
+package env_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/autofix/cli/internal/env"
+)
+
+func TestInstalledPackages(t *testing.T) {
+	tests := []struct {
+		name   string
+		pm     env.PackageManager
+		output string
+		want   []string
+	}{
+		{
+			name: "apt new and upgraded",
+			pm:   env.PMApt,
+			output: `Reading package lists...
+The following additional packages will be installed:
+  libfoo1 libbar2:amd64
+The following NEW packages will be installed:
+  libfoo1 libbar2:amd64 sl
+The following packages will be upgraded:
+  curl
+1 upgraded, 3 newly installed, 0 to remove and 0 not upgraded.
+Setting up libfoo1 (1.0-1) ...
+Setting up curl (8.5.0-2) ...
+Setting up sl (5.02-1) ...
+`,
+			want: []string{"libfoo1", "libbar2", "sl"},
+		},
+		{
+			name: "apt reinstall",
+			pm:   env.PMApt,
+			output: `0 upgraded, 0 newly installed, 1 reinstalled, 0 to remove and 0 not upgraded.
+Setting up sl (5.02-1) ...
+`,
+			want: []string{},
+		},
+		{
+			name: "apt 3",
+			pm:   env.PMApt,
+			output: `Installing:
+  sl
+Installing dependencies:
+  libncurses6
+Upgrading:
+  curl
+Summary:
+  Upgrading: 1, Installing: 2, Removing: 0, Not Upgrading: 0
+`,
+			want: []string{"sl", "libncurses6"},
+		},
+		{
+			name: "dnf",
+			pm:   env.PMDnf,
+			output: `  Installing       : sl-5.02-1.fc39.x86_64     1/2
+  Upgrading        : curl-8.2.1-4.fc39.x86_64   2/2
+  Reinstalling     : jq-1.7-1.fc39.x86_64      1/1
+`,
+			want: []string{"sl"},
+		},
+		{
+			name: "pacman",
+			pm:   env.PMPacman,
+			output: `warning: jq-1.7-1 is up to date -- reinstalling
+(1/3) installing sl                 [######] 100%
+(2/3) reinstalling jq               [######] 100%
+(3/3) upgrading curl                [######] 100%
+`,
+			want: []string{"sl"},
+		},
+		{
+			name: "brew",
+			pm:   env.PMBrew,
+			output: `==> Pouring sl--5.02.arm64_sonoma.bottle.tar.gz
+==> Upgrading jq
+  1.6 -> 1.7
+==> Pouring jq--1.7.arm64_sonoma.bottle.tar.gz
+`,
+			want: []string{"sl"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pm.InstalledPackages(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InstalledPackages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
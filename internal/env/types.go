@@ -42,5 +42,6 @@ type Environment struct {
 	PackageManager PackageManager `json:"package_manager"`
 	Runtimes       []Runtime      `json:"runtimes"`
 	HasSudo        bool           `json:"has_sudo"`
+	IsRoot         bool           `json:"is_root"`
 	InContainer    bool           `json:"in_container"`
 }
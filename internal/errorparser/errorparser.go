@@ -1,9 +1,44 @@
 package errorparser
 
 import (
+	"regexp"
 	"strings"
 )
 
+var portPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)port (\d{2,5})\b`),
+	regexp.MustCompile(`:(\d{2,5})\b`),
+}
+
+var missingCommandPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`command not found: (\S+)`),
+	regexp.MustCompile(`([^\s:]+): command not found`),
+	regexp.MustCompile(`"([^"]+)": executable file not found`),
+	shellNotFoundPattern,
+}
+
+// shellNotFoundPattern matches dash/sh reporting a missing command, as in
+// "/bin/sh: 1: protoc: not found".
+var shellNotFoundPattern = regexp.MustCompile(`\bsh: (?:line )?\d+: ([^\s:]+): not found`)
+
+// javaHomePattern matches complaints about JAVA_HOME itself, not every
+// log line that happens to print it.
+var javaHomePattern = regexp.MustCompile(`(?i)JAVA_HOME[^\n]*\b(?:not (?:set|defined|found)|invalid|does not (?:exist|point))|set (?:the |your )?JAVA_HOME`)
+
+var networkPhrases = []string{
+	"could not resolve host", "temporary failure in name resolution", "connection timed out", "network is unreachable",
+	"proxy error", "tunneling socket", "proxyconnect", "from proxy after connect", "proxy authentication required", "could not resolve proxy", "unable to connect to proxy",
+}
+
+var compilerPattern = regexp.MustCompile(`(?:^|[^\w+-])(gcc|g\+\+|cc|c\+\+|clang\+\+|clang)(?:[^\w+-]|$)`)
+
+var deniedPathPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`'(/[^']+)'`),
+	regexp.MustCompile(`unix://(/[^\s:]+)`),
+	regexp.MustCompile(`((?:\.{1,2})?/[^\s:'"]+):?\s*(?i:permission denied)`),
+	regexp.MustCompile(`((?:\.{1,2})?/[^\s:'"]+)`),
+}
+
 type ErrorType string
 
 const (
@@ -15,6 +50,10 @@ const (
 	ErrorTypeMissingBuildTools      ErrorType = "missing_build_tools"
 	ErrorTypePackageManagerNotFound ErrorType = "package_manager_not_found"
 	ErrorTypeArchitectureMismatch   ErrorType = "architecture_mismatch"
+	ErrorTypeCertificate            ErrorType = "certificate"
+	ErrorTypeJavaHome               ErrorType = "java_home"
+	ErrorTypePkgConfig              ErrorType = "pkg_config"
+	ErrorTypeNetwork                ErrorType = "network"
 	ErrorTypeUnknown                ErrorType = "unknown"
 )
 
@@ -24,12 +63,13 @@ type ErrorInfo struct {
 	Command string    `json:"command,omitempty"`
 	Port    string    `json:"port,omitempty"`
 	Package string    `json:"package,omitempty"`
+	Path    string    `json:"path,omitempty"`
 }
 
 func Parse(stderr string, exitCode int) *ErrorInfo {
 	lowerStderr := strings.ToLower(stderr)
 
-	if strings.Contains(lowerStderr, "command not found") || strings.Contains(lowerStderr, "executable file not found") {
+	if strings.Contains(lowerStderr, "command not found") || strings.Contains(lowerStderr, "executable file not found") || shellNotFoundPattern.MatchString(stderr) {
 		cmd := extractCommand(stderr)
 		return &ErrorInfo{
 			Type:    ErrorTypeMissingCommand,
@@ -38,12 +78,32 @@ func Parse(stderr string, exitCode int) *ErrorInfo {
 		}
 	}
 
-	if strings.Contains(lowerStderr, "gcc") || strings.Contains(lowerStderr, "cc") || strings.Contains(lowerStderr, "compiler") {
+	if strings.Contains(lowerStderr, "certificate verify failed") || strings.Contains(lowerStderr, "unable to get local issuer certificate") || strings.Contains(lowerStderr, "self signed certificate") || strings.Contains(lowerStderr, "self-signed certificate") {
+		return &ErrorInfo{
+			Type:    ErrorTypeCertificate,
+			Message: "TLS certificate verification failed",
+		}
+	}
+
+	if strings.Contains(lowerStderr, "pkg-config search path") || strings.Contains(lowerStderr, "no package '") {
+		pkg := extractPkgConfigPackage(stderr)
+		return &ErrorInfo{
+			Type:    ErrorTypePkgConfig,
+			Message: "pkg-config package not found",
+			Package: pkg,
+		}
+	}
+
+	if compiler := compilerPattern.FindStringSubmatch(stderr); compiler != nil || strings.Contains(lowerStderr, "compiler") {
 		if strings.Contains(lowerStderr, "not found") || strings.Contains(lowerStderr, "no such file") {
-			return &ErrorInfo{
+			info := &ErrorInfo{
 				Type:    ErrorTypeMissingCompiler,
 				Message: "Compiler not found",
 			}
+			if compiler != nil {
+				info.Command = compiler[1]
+			}
+			return info
 		}
 	}
 
@@ -56,7 +116,7 @@ func Parse(stderr string, exitCode int) *ErrorInfo {
 		}
 	}
 
-	if strings.Contains(lowerStderr, "address already in use") || strings.Contains(lowerStderr, "port is already in use") {
+	if strings.Contains(lowerStderr, "address already in use") || strings.Contains(lowerStderr, "port is already in use") || strings.Contains(stderr, "EADDRINUSE") {
 		port := extractPort(stderr)
 		return &ErrorInfo{
 			Type:    ErrorTypePortInUse,
@@ -69,6 +129,21 @@ func Parse(stderr string, exitCode int) *ErrorInfo {
 		return &ErrorInfo{
 			Type:    ErrorTypePermissionDenied,
 			Message: "Permission denied",
+			Path:    extractDeniedPath(stderr),
+		}
+	}
+
+	if javaHomePattern.MatchString(stderr) {
+		return &ErrorInfo{
+			Type:    ErrorTypeJavaHome,
+			Message: "JAVA_HOME is not set or invalid",
+		}
+	}
+
+	if containsAny(lowerStderr, networkPhrases) {
+		return &ErrorInfo{
+			Type:    ErrorTypeNetwork,
+			Message: "Network connection failed",
 		}
 	}
 
@@ -92,18 +167,19 @@ func Parse(stderr string, exitCode int) *ErrorInfo {
 	}
 }
 
+func containsAny(s string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(s, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
 func extractCommand(stderr string) string {
-	lines := strings.Split(stderr, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "command not found") {
-			parts := strings.Fields(line)
-			if len(parts) > 0 {
-				cmd := parts[len(parts)-1]
-				if strings.HasPrefix(cmd, "'") || strings.HasPrefix(cmd, "\"") {
-					cmd = cmd[1 : len(cmd)-1]
-				}
-				return cmd
-			}
+	for _, pattern := range missingCommandPatterns {
+		if m := pattern.FindStringSubmatch(stderr); m != nil {
+			return strings.Trim(m[1], `'"`)
 		}
 	}
 	return ""
@@ -120,25 +196,43 @@ func extractLibrary(stderr string) string {
 	return ""
 }
 
-func extractPort(stderr string) string {
-	parts := strings.Fields(stderr)
-	for _, part := range parts {
-		if strings.HasSuffix(part, ":") {
-			num := part[:len(part)-1]
-			if _, err := parseInt(num); err == nil && len(num) <= 5 {
-				return num
+func extractPkgConfigPackage(stderr string) string {
+	for _, line := range strings.Split(stderr, "\n") {
+		if idx := strings.Index(line, "No package '"); idx >= 0 {
+			rest := line[idx+len("No package '"):]
+			if end := strings.Index(rest, "'"); end >= 0 {
+				return rest[:end]
+			}
+		}
+		if strings.HasPrefix(line, "Package ") && strings.Contains(line, "pkg-config search path") {
+			parts := strings.Fields(line)
+			if len(parts) > 1 {
+				return strings.TrimSuffix(parts[1], ",")
+			}
+		}
+	}
+	return ""
+}
+
+func extractDeniedPath(stderr string) string {
+	for _, line := range strings.Split(stderr, "\n") {
+		if !strings.Contains(strings.ToLower(line), "permission denied") {
+			continue
+		}
+		for _, pattern := range deniedPathPatterns {
+			if m := pattern.FindStringSubmatch(line); m != nil {
+				return m[1]
 			}
 		}
 	}
 	return ""
 }
 
-func parseInt(s string) (int, error) {
-	var result int
-	for _, c := range s {
-		if c >= '0' && c <= '9' {
-			result = result*10 + int(c-'0')
+func extractPort(stderr string) string {
+	for _, pattern := range portPatterns {
+		if m := pattern.FindStringSubmatch(stderr); m != nil {
+			return m[1]
 		}
 	}
-	return result, nil
+	return ""
 }
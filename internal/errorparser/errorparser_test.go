@@ -0,0 +1,63 @@
+package errorparser_test
+
+import (
+	"testing"
+
+	"github.com/autofix/cli/internal/errorparser"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   errorparser.ErrorInfo
+	}{
+		{"bash missing command", "bash: protoc: command not found\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeMissingCommand, Command: "protoc"}},
+		{"zsh missing command", "zsh: command not found: rg\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeMissingCommand, Command: "rg"}},
+		{"dash missing command", "/bin/sh: 1: protoc: not found\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeMissingCommand, Command: "protoc"}},
+		{"exec missing command", `exec: "node": executable file not found in $PATH`, errorparser.ErrorInfo{Type: errorparser.ErrorTypeMissingCommand, Command: "node"}},
+		{"certificate", "curl: (60) SSL certificate problem: unable to get local issuer certificate\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeCertificate}},
+		{"pkg-config", "No package 'openssl' found\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypePkgConfig, Package: "openssl"}},
+		{"pkg-config search path", "Package libffi was not found in the pkg-config search path.\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypePkgConfig, Package: "libffi"}},
+		{"compiler", "make: gcc: No such file or directory\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeMissingCompiler, Command: "gcc"}},
+		{"library", "/usr/bin/ld: cannot find -lssl\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeMissingLibrary, Package: "ssl"}},
+		{"port", "Error: listen EADDRINUSE: address already in use :::3000\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypePortInUse, Port: "3000"}},
+		{"permission quoted path", "mkdir: cannot create directory '/opt/app': Permission denied\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypePermissionDenied, Path: "/opt/app"}},
+		{"permission socket", "permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock: Get ...\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypePermissionDenied, Path: "/var/run/docker.sock"}},
+		{"permission script", "bash: ./build.sh: Permission denied\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypePermissionDenied, Path: "./build.sh"}},
+		{"java home", "ERROR: JAVA_HOME is not set and no 'java' command could be found\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeJavaHome}},
+		{"java home in a log line", "Using JAVA_HOME=/usr/lib/jvm/java-17\nBUILD FAILED\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeUnknown}},
+		{"network", "curl: (6) Could not resolve host: example.com\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeNetwork}},
+		{"proxy", "fatal: unable to access 'https://github.com/': Received HTTP code 407 from proxy after CONNECT\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeNetwork}},
+		{"unknown", "something went wrong\n", errorparser.ErrorInfo{Type: errorparser.ErrorTypeUnknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorparser.Parse(tt.stderr, 1)
+			if got.Type != tt.want.Type || got.Command != tt.want.Command || got.Package != tt.want.Package || got.Port != tt.want.Port || got.Path != tt.want.Path {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.stderr, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"numbers and temp paths", "error at /tmp/go-build123/main.go:12: pid 4411\n", "error at /tmp/go-build987/main.go:40: pid 17\n", true},
+		{"case and spacing", "Error:   Connection   refused\n", "error: connection refused\n", true},
+		{"different command", "bash: protoc: command not found\n", "bash: rg: command not found\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := errorparser.Fingerprint(errorparser.Parse(tt.a, 1), tt.a)
+			b := errorparser.Fingerprint(errorparser.Parse(tt.b, 1), tt.b)
+			if (a == b) != tt.same {
+				t.Errorf("fingerprints %s and %s, want same = %v", a, b, tt.same)
+			}
+		})
+	}
+}
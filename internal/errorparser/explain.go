@@ -0,0 +1,89 @@
+package errorparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxEvidence = 5
+
+// markers are the lowercase phrases Parse keys on for each error type.
+var markers = map[ErrorType][]string{
+	ErrorTypeMissingCommand:         {"command not found", "executable file not found", ": not found"},
+	ErrorTypeCertificate:            {"certificate verify failed", "unable to get local issuer certificate", "self signed certificate", "self-signed certificate"},
+	ErrorTypeJavaHome:               {"java_home"},
+	ErrorTypePkgConfig:              {"pkg-config search path", "no package '"},
+	ErrorTypeNetwork:                {"could not resolve host", "temporary failure in name resolution", "connection timed out", "network is unreachable", "proxy"},
+	ErrorTypeMissingCompiler:        {"not found", "no such file"},
+	ErrorTypeMissingLibrary:         {"cannot find -l", "shared library"},
+	ErrorTypePortInUse:              {"address already in use", "port is already in use", "eaddrinuse"},
+	ErrorTypePermissionDenied:       {"permission denied"},
+	ErrorTypeMissingBuildTools:      {"c compiler", "make"},
+	ErrorTypePackageManagerNotFound: {"package manager"},
+}
+
+// Evidence returns the log lines that support the classification, at
+// most a handful, in log order.
+func Evidence(stderr string, info *ErrorInfo) []string {
+	keys := markers[info.Type]
+	for _, field := range []string{info.Command, info.Package, info.Port, info.Path} {
+		if field != "" && info.Type != ErrorTypeMissingBuildTools {
+			keys = append(keys, strings.ToLower(field))
+		}
+	}
+
+	evidence := []string{}
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+		for _, key := range keys {
+			if line != "" && strings.Contains(lower, key) {
+				evidence = append(evidence, line)
+				break
+			}
+		}
+		if len(evidence) == maxEvidence {
+			break
+		}
+	}
+	return evidence
+}
+
+// Cause describes the usual reason behind a classified error.
+func Cause(info *ErrorInfo) string {
+	switch info.Type {
+	case ErrorTypeMissingCommand:
+		return fmt.Sprintf("%s is not installed, or is installed outside PATH", orUnknown(info.Command, "the command"))
+	case ErrorTypeMissingCompiler:
+		return fmt.Sprintf("no compiler (%s) is installed", orUnknown(info.Command, "cc"))
+	case ErrorTypeMissingLibrary:
+		return fmt.Sprintf("the %s library or its development package is not installed", orUnknown(info.Package, "required"))
+	case ErrorTypePortInUse:
+		return fmt.Sprintf("another process is already listening on port %s", orUnknown(info.Port, "the requested"))
+	case ErrorTypePermissionDenied:
+		return fmt.Sprintf("the current user lacks permission on %s", orUnknown(info.Path, "a file or directory"))
+	case ErrorTypeMissingBuildTools:
+		return "build tools such as make or a C compiler are missing"
+	case ErrorTypePackageManagerNotFound:
+		return "the expected package manager is not installed"
+	case ErrorTypeArchitectureMismatch:
+		return "a binary was built for a different CPU architecture"
+	case ErrorTypeCertificate:
+		return "TLS verification failed: the CA bundle is missing or a proxy re-signs traffic"
+	case ErrorTypeJavaHome:
+		return "JAVA_HOME is unset or points to a missing JDK"
+	case ErrorTypePkgConfig:
+		return fmt.Sprintf("pkg-config cannot find %s; its development package is probably missing", orUnknown(info.Package, "a package"))
+	case ErrorTypeNetwork:
+		return "DNS resolution or the network connection failed; check connectivity and proxy settings"
+	default:
+		return "no deterministic rule recognises this failure"
+	}
+}
+
+func orUnknown(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,41 @@
+package errorparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+const fingerprintLines = 20
+
+var (
+	hexPattern    = regexp.MustCompile(`0x[0-9a-f]+`)
+	numberPattern = regexp.MustCompile(`[0-9]+`)
+	tmpPattern    = regexp.MustCompile(`/tmp/[^\s:'"]+`)
+	spacePattern  = regexp.MustCompile(`\s+`)
+)
+
+func Fingerprint(info *ErrorInfo, stderr string) string {
+	lines := []string{}
+	for _, line := range strings.Split(stderr, "\n") {
+		if line = normalizeLine(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > fingerprintLines {
+		lines = lines[len(lines)-fingerprintLines:]
+	}
+
+	key := strings.Join([]string{string(info.Type), info.Command, info.Package, info.Port}, "|")
+	sum := sha256.Sum256([]byte(key + "\n" + strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:6])
+}
+
+func normalizeLine(line string) string {
+	line = strings.ToLower(strings.TrimSpace(line))
+	line = tmpPattern.ReplaceAllString(line, "/tmp/*")
+	line = hexPattern.ReplaceAllString(line, "0x*")
+	line = numberPattern.ReplaceAllString(line, "N")
+	return spacePattern.ReplaceAllString(line, " ")
+}
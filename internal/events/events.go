@@ -0,0 +1,159 @@
+package events
+
+import (
+	"time"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+)
+
+// Event is something that happened while healing a command. Every
+// event type is a plain struct so sinks can render or encode it.
+type Event interface {
+	Name() string
+}
+
+type Sink interface {
+	Handle(Event)
+}
+
+type SinkFunc func(Event)
+
+func (f SinkFunc) Handle(e Event) { f(e) }
+
+// Bus fans events out to its sinks. A nil Bus drops everything.
+type Bus struct {
+	sinks []Sink
+}
+
+func NewBus(sinks ...Sink) *Bus {
+	return &Bus{sinks: sinks}
+}
+
+func (b *Bus) Subscribe(s Sink) {
+	b.sinks = append(b.sinks, s)
+}
+
+func (b *Bus) Emit(e Event) {
+	if b == nil {
+		return
+	}
+	for _, s := range b.sinks {
+		s.Handle(e)
+	}
+}
+
+// Fix describes a fix plan independently of the engine's own types.
+type Fix struct {
+	Type         string   `json:"type"`
+	Source       string   `json:"source"`
+	RiskLevel    string   `json:"risk_level"`
+	Explanation  string   `json:"explanation,omitempty"`
+	Steps        []string `json:"steps"`
+	RequiresSudo bool     `json:"requires_sudo"`
+}
+
+type EnvironmentDetected struct {
+	Host        string           `json:"host,omitempty"`
+	Environment *env.Environment `json:"environment"`
+}
+
+type AttemptStarted struct {
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	Command     string `json:"command"`
+}
+
+type CommandFinished struct {
+	Attempt int              `json:"attempt"`
+	Result  *executor.Result `json:"result"`
+}
+
+type ErrorClassified struct {
+	Attempt     int                    `json:"attempt"`
+	Fingerprint string                 `json:"fingerprint"`
+	Error       *errorparser.ErrorInfo `json:"error"`
+}
+
+// Notice is a diagnostic finding or warning, rendered as "[Tag] text".
+type Notice struct {
+	Tag  string `json:"tag"`
+	Text string `json:"text"`
+}
+
+type SuggestionReceived struct {
+	Fix *Fix `json:"fix"`
+}
+
+type SandboxTrial struct {
+	Commands []string          `json:"commands"`
+	Changes  []executor.Change `json:"changes,omitempty"`
+	Success  bool              `json:"success"`
+	Failed   *executor.Result  `json:"failed,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type FixProposed struct {
+	Attempt int  `json:"attempt"`
+	Fix     *Fix `json:"fix"`
+}
+
+type FixConfirmed struct {
+	Fix    *Fix `json:"fix"`
+	Edited bool `json:"edited,omitempty"`
+}
+
+type EnvironmentSet struct {
+	Var string `json:"var"`
+}
+
+type FixFailed struct {
+	Command string           `json:"command"`
+	Result  *executor.Result `json:"result"`
+}
+
+type FixApplied struct {
+	Fix    *Fix             `json:"fix"`
+	Result *executor.Result `json:"result,omitempty"`
+}
+
+type RollbackOffered struct {
+	Commands []string `json:"commands"`
+}
+
+type RolledBack struct {
+	Command  string `json:"command"`
+	Rollback string `json:"rollback"`
+	Success  bool   `json:"success"`
+}
+
+type BackoffStarted struct {
+	Delay time.Duration `json:"delay"`
+}
+
+type SessionFinished struct {
+	Command  string `json:"command"`
+	Attempts int    `json:"attempts"`
+	Success  bool   `json:"success"`
+	Outcome  string `json:"outcome"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (EnvironmentDetected) Name() string { return "environment_detected" }
+func (AttemptStarted) Name() string      { return "attempt_started" }
+func (CommandFinished) Name() string     { return "command_finished" }
+func (ErrorClassified) Name() string     { return "error_classified" }
+func (Notice) Name() string              { return "notice" }
+func (SuggestionReceived) Name() string  { return "suggestion_received" }
+func (SandboxTrial) Name() string        { return "sandbox_trial" }
+func (FixProposed) Name() string         { return "fix_proposed" }
+func (FixConfirmed) Name() string        { return "fix_confirmed" }
+func (EnvironmentSet) Name() string      { return "environment_set" }
+func (FixFailed) Name() string           { return "fix_failed" }
+func (FixApplied) Name() string          { return "fix_applied" }
+func (RollbackOffered) Name() string     { return "rollback_offered" }
+func (RolledBack) Name() string          { return "rolled_back" }
+func (BackoffStarted) Name() string      { return "backoff_started" }
+func (SessionFinished) Name() string     { return "session_finished" }
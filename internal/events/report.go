@@ -0,0 +1,78 @@
+package events
+
+import (
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+)
+
+// Report collects a session's events into a single document.
+type Report struct {
+	Command     string           `json:"command"`
+	Host        string           `json:"host,omitempty"`
+	Environment *env.Environment `json:"environment,omitempty"`
+	Attempts    []*Attempt       `json:"attempts"`
+	Outcome     string           `json:"outcome"`
+	ExitCode    int              `json:"exit_code"`
+	Error       string           `json:"error,omitempty"`
+}
+
+type Attempt struct {
+	Attempt     int                    `json:"attempt"`
+	Result      *executor.Result       `json:"result,omitempty"`
+	Fingerprint string                 `json:"fingerprint,omitempty"`
+	Error       *errorparser.ErrorInfo `json:"error,omitempty"`
+	Proposed    *Fix                   `json:"proposed_fix,omitempty"`
+	Applied     *Fix                   `json:"applied_fix,omitempty"`
+	FixResult   *executor.Result       `json:"fix_result,omitempty"`
+	FixFailed   *executor.Result       `json:"fix_failed,omitempty"`
+}
+
+func NewReport() *Report {
+	return &Report{Attempts: []*Attempt{}}
+}
+
+func (r *Report) Handle(e Event) {
+	switch e := e.(type) {
+	case EnvironmentDetected:
+		r.Host = e.Host
+		r.Environment = e.Environment
+	case AttemptStarted:
+		r.Command = e.Command
+		r.Attempts = append(r.Attempts, &Attempt{Attempt: e.Attempt})
+	case CommandFinished:
+		if a := r.current(); a != nil {
+			a.Result = e.Result
+		}
+	case ErrorClassified:
+		if a := r.current(); a != nil {
+			a.Fingerprint = e.Fingerprint
+			a.Error = e.Error
+		}
+	case FixProposed:
+		if a := r.current(); a != nil {
+			a.Proposed = e.Fix
+		}
+	case FixFailed:
+		if a := r.current(); a != nil {
+			a.FixFailed = e.Result
+		}
+	case FixApplied:
+		if a := r.current(); a != nil {
+			a.Applied = e.Fix
+			a.FixResult = e.Result
+		}
+	case SessionFinished:
+		r.Command = e.Command
+		r.Outcome = e.Outcome
+		r.ExitCode = e.ExitCode
+		r.Error = e.Error
+	}
+}
+
+func (r *Report) current() *Attempt {
+	if len(r.Attempts) == 0 {
+		return nil
+	}
+	return r.Attempts[len(r.Attempts)-1]
+}
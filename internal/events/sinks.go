@@ -0,0 +1,186 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Console renders events as the human-readable "[Tag] ..." lines.
+type Console struct {
+	w io.Writer
+}
+
+func NewConsole(w io.Writer) *Console {
+	return &Console{w: w}
+}
+
+func (c *Console) Handle(e Event) {
+	switch e := e.(type) {
+	case EnvironmentDetected:
+		fmt.Fprintf(c.w, "OS: %s\n", e.Environment.OS)
+		fmt.Fprintf(c.w, "Architecture: %s\n", e.Environment.Architecture)
+		fmt.Fprintf(c.w, "Package Manager: %s\n", e.Environment.PackageManager)
+		fmt.Fprintf(c.w, "Has Sudo: %v\n", e.Environment.HasSudo)
+		fmt.Fprintf(c.w, "In Container: %v\n", e.Environment.InContainer)
+	case AttemptStarted:
+		if e.Attempt > 0 {
+			fmt.Fprintf(c.w, "[Retry %d/%d]\n", e.Attempt, e.MaxAttempts)
+		}
+	case Notice:
+		fmt.Fprintf(c.w, "[%s] %s\n", e.Tag, e.Text)
+	case SuggestionReceived:
+		fmt.Fprintf(c.w, "[LLM Suggestion] %s\n", e.Fix.Explanation)
+		for _, step := range e.Fix.Steps {
+			fmt.Fprintf(c.w, "[Proposed Fix] %s\n", step)
+		}
+		fmt.Fprintf(c.w, "[Risk Level] %s\n", e.Fix.RiskLevel)
+	case SandboxTrial:
+		fmt.Fprintf(c.w, "[Sandbox Trial] %s\n", strings.Join(e.Commands, " && "))
+		if e.Error != "" {
+			fmt.Fprintf(c.w, "[Sandbox Trial] unavailable: %s\n", e.Error)
+			break
+		}
+		for _, change := range e.Changes {
+			fmt.Fprintf(c.w, "  %s %s\n", change.Kind, change.Path)
+		}
+		if e.Failed != nil {
+			fmt.Fprintf(c.w, "[Sandbox Trial] failed: %s exited %d\n", e.Failed.Command, e.Failed.ExitCode)
+		} else if e.Success {
+			fmt.Fprintf(c.w, "[Sandbox Trial] passed, %d files changed\n", len(e.Changes))
+		}
+	case FixProposed:
+		for _, step := range e.Fix.Steps {
+			fmt.Fprintf(c.w, "[Applying Fix] %s\n", step)
+		}
+	case EnvironmentSet:
+		fmt.Fprintf(c.w, "[Setting Environment] %s\n", e.Var)
+	case FixFailed:
+		fmt.Fprintf(c.w, "[Fix Failed] %s\n", e.Result.Stderr)
+	case RollbackOffered:
+		fmt.Fprintln(c.w, "[Rollback Available]")
+		for _, command := range e.Commands {
+			fmt.Fprintf(c.w, "  %s\n", command)
+		}
+	case RolledBack:
+		if e.Success {
+			fmt.Fprintf(c.w, "[Rolled Back] %s\n", e.Command)
+		} else {
+			fmt.Fprintf(c.w, "[Rollback Failed] %s\n", e.Rollback)
+		}
+	case BackoffStarted:
+		fmt.Fprintf(c.w, "[Backoff] waiting %s\n", e.Delay)
+	}
+}
+
+// NDJSON writes one JSON object per event, with the event name and a
+// timestamp alongside the event's own fields.
+type NDJSON struct {
+	w   io.Writer
+	Now func() time.Time
+}
+
+func NewNDJSON(w io.Writer) *NDJSON {
+	return &NDJSON{w: w, Now: time.Now}
+}
+
+func (n *NDJSON) Handle(e Event) {
+	fields, err := Fields(e)
+	if err != nil {
+		return
+	}
+	fields["event"], _ = json.Marshal(e.Name())
+	fields["time"], _ = json.Marshal(n.Now().UTC())
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+	n.w.Write(append(line, '\n'))
+}
+
+// Fields returns an event's JSON-encoded fields keyed by name.
+func Fields(e Event) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Log writes events as structured log records, at warn level for
+// failures.
+type Log struct {
+	logger *slog.Logger
+}
+
+func NewLog(w io.Writer) *Log {
+	return &Log{logger: slog.New(slog.NewTextHandler(w, nil))}
+}
+
+func (l *Log) Handle(e Event) {
+	fields, err := Fields(e)
+	if err != nil {
+		return
+	}
+	values := map[string]any{}
+	for key, raw := range fields {
+		var value any
+		json.Unmarshal(raw, &value)
+		values[key] = value
+	}
+	l.logger.LogAttrs(context.Background(), level(e), e.Name(), attrs(values)...)
+}
+
+// attrs turns decoded JSON into log attributes, nesting objects as
+// groups so they render as dotted keys.
+func attrs(values map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		switch value := values[key].(type) {
+		case map[string]any:
+			group := attrs(value)
+			out = append(out, slog.Attr{Key: key, Value: slog.GroupValue(group...)})
+		case []any:
+			data, _ := json.Marshal(value)
+			out = append(out, slog.String(key, string(data)))
+		default:
+			out = append(out, slog.Any(key, value))
+		}
+	}
+	return out
+}
+
+func level(e Event) slog.Level {
+	switch e := e.(type) {
+	case FixFailed:
+		return slog.LevelWarn
+	case SandboxTrial:
+		if !e.Success {
+			return slog.LevelWarn
+		}
+	case RolledBack:
+		if !e.Success {
+			return slog.LevelWarn
+		}
+	case SessionFinished:
+		if !e.Success {
+			return slog.LevelWarn
+		}
+	}
+	return slog.LevelInfo
+}
@@ -2,7 +2,11 @@ package executor
 
 import (
 	"bytes"
+	"context"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"syscall"
 )
@@ -16,7 +20,134 @@ type Result struct {
 	Lines    []string `json:"lines"`
 }
 
-var Runner = func(cmd *exec.Cmd) (*Result, error) {
+type Request struct {
+	Command string
+	Dir     string
+	Env     []string
+	Stdin   io.Reader
+}
+
+type Executor interface {
+	Run(ctx context.Context, req *Request) (*Result, error)
+}
+
+// IsLocal reports whether ex runs commands on this machine, looking
+// through wrappers that expose the executor they wrap via Unwrap. An
+// executor that stands in for another, such as a replay, can answer for
+// it with an IsLocal method.
+func IsLocal(ex Executor) bool {
+	for {
+		switch e := ex.(type) {
+		case *Local:
+			return true
+		case interface{ IsLocal() bool }:
+			return e.IsLocal()
+		case interface{ Unwrap() Executor }:
+			ex = e.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+type Local struct{}
+
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (l *Local) Run(ctx context.Context, req *Request) (*Result, error) {
+	args := SplitCommand(req.Command)
+	if len(args) == 0 {
+		args = []string{"/bin/sh", "-c", req.Command}
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = req.Dir
+	cmd.Stdin = req.Stdin
+	if _, ok := ctx.Deadline(); ok {
+		// A command with a deadline may be a shell; kill its whole process
+		// group so that no child keeps running or holds the output open.
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	}
+	if len(req.Env) > 0 {
+		cmd.Env = append(os.Environ(), req.Env...)
+		if path := lookPath(args[0], req.Env); path != "" {
+			cmd.Path = path
+			cmd.Err = nil
+		}
+	}
+	return run(cmd, strings.Join(cmd.Args, " ")), nil
+}
+
+// SplitCommand splits a command line into arguments the way a shell
+// would, honouring quotes and backslashes, but without expanding anything,
+// so that a ShellQuote'd argument means the same here as over ssh.
+func SplitCommand(command string) []string {
+	args := []string{}
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\\\"$`\n", r) {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+// lookPath resolves name against a PATH override in env, which
+// exec.Command would otherwise ignore in favour of our own PATH.
+func lookPath(name string, env []string) string {
+	if strings.Contains(name, "/") {
+		return ""
+	}
+	for i := len(env) - 1; i >= 0; i-- {
+		value, ok := strings.CutPrefix(env[i], "PATH=")
+		if !ok {
+			continue
+		}
+		for _, dir := range filepath.SplitList(value) {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+				return path
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+func run(cmd *exec.Cmd, command string) *Result {
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -24,7 +155,7 @@ var Runner = func(cmd *exec.Cmd) (*Result, error) {
 	err := cmd.Run()
 
 	result := &Result{
-		Command: strings.Join(cmd.Args, " "),
+		Command: command,
 		Stdout:  stdout.String(),
 		Stderr:  stderr.String(),
 		Lines:   strings.Split(stdout.String(), "\n"),
@@ -33,12 +164,15 @@ var Runner = func(cmd *exec.Cmd) (*Result, error) {
 	if err != nil {
 		result.ExitCode = getExitCode(err)
 		result.Success = false
+		if _, exited := err.(*exec.ExitError); !exited {
+			result.Stderr += err.Error() + "\n"
+		}
 	} else {
 		result.ExitCode = 0
 		result.Success = true
 	}
 
-	return result, nil
+	return result
 }
 
 func getExitCode(err error) int {
@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"context"
+	"strings"
+)
+
+const SandboxInitCommand = "__sandbox-init"
+
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeModified ChangeKind = "modified"
+	ChangeDeleted  ChangeKind = "deleted"
+)
+
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+}
+
+type TrialResult struct {
+	Results []*Result `json:"results"`
+	Changes []Change  `json:"changes"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+type sandboxSpec struct {
+	Lower   string   `json:"lower"`
+	Scratch string   `json:"scratch"`
+	Dir     string   `json:"dir"`
+	Env     []string `json:"env"`
+	Steps   []string `json:"steps"`
+}
+
+func (s *Sandbox) Run(ctx context.Context, req *Request) (*Result, error) {
+	trial, err := s.Trial(ctx, req.Dir, req.Env, req.Command)
+	if err != nil {
+		return nil, err
+	}
+	return trial.Results[len(trial.Results)-1], nil
+}
+
+func sandboxStep(step string) string {
+	step = strings.TrimSpace(step)
+	if strings.HasPrefix(step, "sudo ") || strings.HasPrefix(step, "sudo\t") {
+		return strings.TrimSpace(step[len("sudo"):])
+	}
+	return step
+}
@@ -0,0 +1,404 @@
+//go:build linux
+
+package executor
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+type Sandbox struct {
+	lower   string
+	tempDir string
+}
+
+func NewSandbox(rootfs string) (*Sandbox, error) {
+	s := &Sandbox{lower: "/"}
+	if rootfs == "" {
+		return s, nil
+	}
+
+	info, err := os.Stat(rootfs)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		s.lower = rootfs
+		return s, nil
+	}
+
+	s.tempDir, err = os.MkdirTemp("", "autofix-rootfs-")
+	if err != nil {
+		return nil, err
+	}
+	if err := extractTarball(rootfs, s.tempDir); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to extract %s: %w", rootfs, err)
+	}
+	s.lower = s.tempDir
+	return s, nil
+}
+
+func (s *Sandbox) Close() error {
+	if s.tempDir == "" {
+		return nil
+	}
+	return os.RemoveAll(s.tempDir)
+}
+
+func (s *Sandbox) Trial(ctx context.Context, dir string, env []string, steps ...string) (*TrialResult, error) {
+	scratch, err := os.MkdirTemp("", "autofix-sandbox-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	// Steps run where the command would: the caller's working directory,
+	// which the same path reaches inside the chroot.
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := json.Marshal(&sandboxSpec{Lower: s.lower, Scratch: scratch, Dir: dir, Env: env, Steps: steps})
+	if err != nil {
+		return nil, err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, self, SandboxInitCommand)
+	cmd.Stdin = bytes.NewReader(spec)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+	}
+
+	if err := cmd.Run(); err != nil && stdout.Len() == 0 {
+		return nil, fmt.Errorf("sandbox failed to start: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var trial TrialResult
+	if err := json.Unmarshal(stdout.Bytes(), &trial); err != nil {
+		return nil, fmt.Errorf("invalid sandbox report: %w", err)
+	}
+	if trial.Error != "" {
+		return nil, fmt.Errorf("sandbox: %s", trial.Error)
+	}
+	return &trial, nil
+}
+
+func SandboxInit() {
+	trial, err := sandboxInit()
+	if err != nil {
+		trial = &TrialResult{Error: err.Error()}
+	}
+	json.NewEncoder(os.Stdout).Encode(trial)
+	os.Exit(0)
+}
+
+func sandboxInit() (*TrialResult, error) {
+	var spec sandboxSpec
+	if err := json.NewDecoder(os.Stdin).Decode(&spec); err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return nil, fmt.Errorf("make mounts private: %w", err)
+	}
+
+	scratch := spec.Scratch
+	if err := syscall.Mount("tmpfs", scratch, "tmpfs", 0, ""); err != nil {
+		return nil, fmt.Errorf("mount tmpfs: %w", err)
+	}
+
+	upper := filepath.Join(scratch, "upper")
+	work := filepath.Join(scratch, "work")
+	merged := filepath.Join(scratch, "merged")
+	for _, dir := range []string{upper, work, merged} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := buildRoot(spec.Lower, upper, work, merged); err != nil {
+		return nil, err
+	}
+	bindResolvConf(merged)
+
+	trial := &TrialResult{Success: true}
+	for _, step := range spec.Steps {
+		cmd := exec.Command("/bin/sh", "-c", sandboxStep(step))
+		cmd.Dir = spec.Dir
+		cmd.Env = append(os.Environ(), spec.Env...)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Chroot: merged}
+
+		result := run(cmd, step)
+		trial.Results = append(trial.Results, result)
+		if !result.Success {
+			trial.Success = false
+			break
+		}
+	}
+
+	trial.Changes = collectChanges(upper, spec.Lower)
+	return trial, nil
+}
+
+func buildRoot(lower, upper, work, merged string) error {
+	entries, err := os.ReadDir(lower)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		source := filepath.Join(lower, name)
+		target := filepath.Join(merged, name)
+
+		info, err := os.Lstat(source)
+		if err != nil {
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(source)
+			if err == nil {
+				os.Symlink(link, target)
+			}
+			continue
+		}
+		if !info.IsDir() {
+			continue
+		}
+		if err := os.Mkdir(target, info.Mode().Perm()); err != nil {
+			return err
+		}
+
+		switch name {
+		case "proc":
+			if err := syscall.Mount("proc", target, "proc", 0, ""); err != nil {
+				return fmt.Errorf("mount /proc: %w", err)
+			}
+			continue
+		case "dev", "sys":
+			if err := syscall.Mount(source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+				return fmt.Errorf("bind /%s: %w", name, err)
+			}
+			continue
+		}
+
+		layerUpper := filepath.Join(upper, name)
+		layerWork := filepath.Join(work, name)
+		if err := os.Mkdir(layerUpper, 0755); err != nil {
+			return err
+		}
+		if err := os.Mkdir(layerWork, 0755); err != nil {
+			return err
+		}
+
+		opts := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s,userxattr", source, layerUpper, layerWork)
+		if err := syscall.Mount("overlay", target, "overlay", 0, opts); err != nil {
+			// Directories with locked submounts cannot be overlaid from a
+			// user namespace; leave them empty rather than exposing the host.
+			continue
+		}
+	}
+	return nil
+}
+
+func bindResolvConf(merged string) {
+	source, err := filepath.EvalSymlinks("/etc/resolv.conf")
+	if err != nil {
+		return
+	}
+	target := filepath.Join(merged, "etc", "resolv.conf")
+	os.Remove(target)
+	if err := os.WriteFile(target, nil, 0644); err != nil {
+		return
+	}
+	syscall.Mount(source, target, "", syscall.MS_BIND, "")
+}
+
+func collectChanges(upper, lower string) []Change {
+	changes := []Change{}
+	filepath.Walk(upper, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == upper {
+			return nil
+		}
+		rel, _ := filepath.Rel(upper, path)
+		if rel == filepath.Join("etc", "resolv.conf") {
+			return nil
+		}
+		if info.IsDir() {
+			if _, err := os.Lstat(filepath.Join(lower, rel)); err == nil {
+				return nil
+			}
+		}
+
+		change := Change{Path: "/" + rel, Kind: ChangeAdded}
+		if isWhiteout(info) {
+			change.Kind = ChangeDeleted
+		} else if _, err := os.Lstat(filepath.Join(lower, rel)); err == nil {
+			change.Kind = ChangeModified
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	return changes
+}
+
+func isWhiteout(info os.FileInfo) bool {
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Rdev == 0
+}
+
+func extractTarball(path, dest string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, filepath.Clean("/"+header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := extractDir(dest, target, true); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := replaceable(dest, target); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|syscall.O_NOFOLLOW, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		case tar.TypeSymlink:
+			// Absolute targets are resolved against the rootfs, as they
+			// are inside the sandbox.
+			resolved := filepath.Join(dest, header.Linkname)
+			if !filepath.IsAbs(header.Linkname) {
+				resolved = filepath.Join(filepath.Dir(target), header.Linkname)
+			}
+			if !within(dest, resolved) {
+				return fmt.Errorf("%s: symlink target %s is outside the rootfs", header.Name, header.Linkname)
+			}
+			if err := replaceable(dest, target); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			source := filepath.Join(dest, filepath.Clean("/"+header.Linkname))
+			if err := extractDir(dest, filepath.Dir(source), false); err != nil {
+				return err
+			}
+			if err := replaceable(dest, target); err != nil {
+				return err
+			}
+			if err := os.Link(source, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// within reports whether path is dest or below it.
+func within(dest, path string) bool {
+	rel, err := filepath.Rel(dest, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// extractDir checks, and with create makes, each directory from dest down
+// to dir, refusing to go through a symlink so that no entry is written
+// through a link an earlier entry created.
+func extractDir(dest, dir string, create bool) error {
+	rel, err := filepath.Rel(dest, dir)
+	if err != nil || !within(dest, dir) {
+		return fmt.Errorf("%s is outside the rootfs", dir)
+	}
+	path := dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		switch {
+		case os.IsNotExist(err) && create:
+			if err := os.Mkdir(path, 0755); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case info.Mode()&os.ModeSymlink != 0:
+			return fmt.Errorf("%s goes through a symlink", dir)
+		case !info.IsDir():
+			return fmt.Errorf("%s is not a directory", path)
+		}
+	}
+	return nil
+}
+
+// replaceable prepares target for a new entry: its directory exists
+// without symlinks on the way, and a file or link already there, from an
+// earlier entry of the same name, is removed rather than written through.
+func replaceable(dest, target string) error {
+	if err := extractDir(dest, filepath.Dir(target), true); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		return os.Remove(target)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build linux
+
+package executor
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	name, link, content string
+	flag                byte
+}
+
+func writeTarball(t *testing.T, entries []entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rootfs.tar")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	tw := tar.NewWriter(file)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Linkname: e.link, Typeflag: e.flag, Mode: 0644, Size: int64(len(e.content))}
+		if e.flag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractTarball(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		err     string
+	}{
+		{
+			name: "rootfs",
+			entries: []entry{
+				{name: "usr/lib/", flag: tar.TypeDir},
+				{name: "usr/lib/libc.so", flag: tar.TypeReg, content: "libc"},
+				{name: "lib", flag: tar.TypeSymlink, link: "usr/lib"},
+				{name: "bin/sh", flag: tar.TypeSymlink, link: "/usr/bin/dash"},
+				{name: "usr/lib/libc.so.6", flag: tar.TypeLink, link: "usr/lib/libc.so"},
+			},
+		},
+		{
+			name:    "relative symlink escaping",
+			entries: []entry{{name: "etc/passwd", flag: tar.TypeSymlink, link: "../../../../etc/passwd"}},
+			err:     "outside the rootfs",
+		},
+		{
+			name: "write through a symlinked directory",
+			entries: []entry{
+				{name: "lib", flag: tar.TypeSymlink, link: "usr/lib"},
+				{name: "lib/evil", flag: tar.TypeReg, content: "x"},
+			},
+			err: "goes through a symlink",
+		},
+		{
+			name: "hardlink through a symlinked directory",
+			entries: []entry{
+				{name: "host", flag: tar.TypeSymlink, link: "/"},
+				{name: "shadow", flag: tar.TypeLink, link: "host/etc/shadow"},
+			},
+			err: "goes through a symlink",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			err := extractTarball(writeTarball(t, tt.entries), dest)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestExtractTarballReplacesLinks(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "outside")
+	if err := os.WriteFile(outside, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	// The link points inside the rootfs as the sandbox sees it, but on the
+	// host it is the outside file; the later entry must not write to it.
+	tarball := writeTarball(t, []entry{
+		{name: "file", flag: tar.TypeSymlink, link: outside},
+		{name: "file", flag: tar.TypeReg, content: "replaced"},
+	})
+	if err := extractTarball(tarball, dest); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(outside); string(content) != "original" {
+		t.Errorf("outside file = %q, want it untouched", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dest, "file")); string(content) != "replaced" {
+		t.Errorf("extracted file = %q, want %q", content, "replaced")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+
+package executor
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrSandboxUnsupported = errors.New("sandbox execution requires Linux namespaces")
+
+type Sandbox struct{}
+
+func NewSandbox(rootfs string) (*Sandbox, error) {
+	return nil, ErrSandboxUnsupported
+}
+
+func (s *Sandbox) Trial(ctx context.Context, dir string, env []string, steps ...string) (*TrialResult, error) {
+	return nil, ErrSandboxUnsupported
+}
+
+func (s *Sandbox) Close() error {
+	return nil
+}
+
+func SandboxInit() {}
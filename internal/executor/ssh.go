@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+type SSH struct {
+	Host    string
+	Options []string
+}
+
+func NewSSH(host string, options ...string) *SSH {
+	return &SSH{Host: host, Options: options}
+}
+
+func (s *SSH) Run(ctx context.Context, req *Request) (*Result, error) {
+	args := []string{"-o", "BatchMode=yes"}
+	args = append(args, s.Options...)
+	args = append(args, s.Host, "--", remoteScript(req))
+
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	cmd.Stdin = req.Stdin
+	result := run(cmd, req.Command)
+
+	// ssh exits 255 when it fails itself, whatever the reason: resolving,
+	// connecting, host key checks or authentication.
+	if result.ExitCode == 255 {
+		message := strings.TrimSpace(result.Stderr)
+		if message == "" {
+			message = "ssh exited with status 255"
+		}
+		return result, &ConnectionError{Host: s.Host, Message: message}
+	}
+	return result, nil
+}
+
+func remoteScript(req *Request) string {
+	parts := []string{}
+	if req.Dir != "" {
+		parts = append(parts, "cd "+ShellQuote(req.Dir))
+	}
+	for _, v := range req.Env {
+		key, value, ok := strings.Cut(v, "=")
+		if ok {
+			parts = append(parts, "export "+key+"="+ShellQuote(value))
+		}
+	}
+	parts = append(parts, req.Command)
+	return strings.Join(parts, " && ")
+}
+
+// ShellQuote quotes s for a POSIX shell, unless it is a plain word that
+// needs no quoting.
+func ShellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type ConnectionError struct {
+	Host    string
+	Message string
+}
+
+func (e *ConnectionError) Error() string {
+	return "ssh connection to " + e.Host + " failed: " + e.Message
+}
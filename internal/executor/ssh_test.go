@@ -0,0 +1,177 @@
+package executor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/autofix/cli/internal/executor"
+)
+
+// freePort returns a local TCP port with nothing listening on it.
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port
+}
+
+func sshOptions(port, key string) []string {
+	return []string{"-p", port, "-i", key, "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null", "-o", "ConnectTimeout=5", "-o", "LogLevel=ERROR"}
+}
+
+func TestSSHConnectionRefused(t *testing.T) {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		t.Skip("no ssh client")
+	}
+	ex := executor.NewSSH("127.0.0.1", sshOptions(freePort(t), "/dev/null")...)
+	_, err := ex.Run(context.Background(), &executor.Request{Command: "true"})
+	var connErr *executor.ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Errorf("error = %v, want a connection error", err)
+	}
+}
+
+func TestSSHHandshakeFailure(t *testing.T) {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		t.Skip("no ssh client")
+	}
+	// A server that hangs up before the handshake: ssh reports it without
+	// the "ssh:" prefix of a refused connection.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	result, err := executor.NewSSH("127.0.0.1", sshOptions(port, "/dev/null")...).Run(context.Background(), &executor.Request{Command: "true"})
+	var connErr *executor.ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Errorf("error = %v, want a connection error (stderr %q)", err, result.Stderr)
+	}
+}
+
+// startSSHD runs sshd on a free local port, accepting the returned key
+// for the current user.
+func startSSHD(t *testing.T) (host, port, key string) {
+	t.Helper()
+	sshd, err := exec.LookPath("sshd")
+	if err != nil {
+		sshd = "/usr/sbin/sshd"
+	}
+	if _, err := os.Stat(sshd); err != nil {
+		t.Skip("no sshd")
+	}
+	current, err := user.Current()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	dir := t.TempDir()
+	key = filepath.Join(dir, "id_ed25519")
+	hostKey := filepath.Join(dir, "host_ed25519")
+	for _, path := range []string{key, hostKey} {
+		if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", path).CombinedOutput(); err != nil {
+			t.Skipf("ssh-keygen: %v: %s", err, out)
+		}
+	}
+	public, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized := filepath.Join(dir, "authorized_keys")
+	if err := os.WriteFile(authorized, public, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port = freePort(t)
+	config := filepath.Join(dir, "sshd_config")
+	settings := fmt.Sprintf("ListenAddress 127.0.0.1\nPort %s\nHostKey %s\nAuthorizedKeysFile %s\nPidFile %s\nStrictModes no\nUsePAM no\nPasswordAuthentication no\n",
+		port, hostKey, authorized, filepath.Join(dir, "sshd.pid"))
+	if err := os.WriteFile(config, []byte(settings), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(sshd, "-D", "-e", "-f", config)
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sshd: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", "127.0.0.1:"+port); err == nil {
+			conn.Close()
+			return current.Username + "@127.0.0.1", port, key
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Skip("sshd did not start")
+	return
+}
+
+func TestSSHRun(t *testing.T) {
+	host, port, key := startSSHD(t)
+	ex := executor.NewSSH(host, sshOptions(port, key)...)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		req      *executor.Request
+		exitCode int
+		stdout   string
+	}{
+		{name: "output", req: &executor.Request{Command: "echo hello"}, stdout: "hello"},
+		{name: "dir and env", req: &executor.Request{Command: `echo "$GREETING" && pwd`, Dir: dir, Env: []string{"GREETING=it's me"}}, stdout: "it's me\n" + dir},
+		{name: "exit code", req: &executor.Request{Command: "exit 3"}, exitCode: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ex.Run(context.Background(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ExitCode != tt.exitCode {
+				t.Errorf("exit code = %d, want %d (stderr %q)", result.ExitCode, tt.exitCode, result.Stderr)
+			}
+			if strings.TrimSpace(result.Stdout) != tt.stdout {
+				t.Errorf("stdout = %q, want %q", result.Stdout, tt.stdout)
+			}
+		})
+	}
+
+	t.Run("unknown key", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "other")
+		if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", other).CombinedOutput(); err != nil {
+			t.Fatalf("ssh-keygen: %v: %s", err, out)
+		}
+		_, err := executor.NewSSH(host, sshOptions(port, other)...).Run(context.Background(), &executor.Request{Command: "true"})
+		var connErr *executor.ConnectionError
+		if !errors.As(err, &connErr) {
+			t.Errorf("error = %v, want a connection error", err)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package fallback
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Method string
+
+const (
+	MethodPip    Method = "pip"
+	MethodNPM    Method = "npm"
+	MethodCargo  Method = "cargo"
+	MethodGo     Method = "go"
+	MethodBrew   Method = "brew"
+	MethodConda  Method = "conda"
+	MethodBinary Method = "binary"
+)
+
+var DefaultOrder = []string{
+	string(MethodPip),
+	string(MethodNPM),
+	string(MethodCargo),
+	string(MethodGo),
+	string(MethodBrew),
+	string(MethodConda),
+	string(MethodBinary),
+}
+
+// catalog maps a command to the package that provides it for each
+// language package manager. Brew and conda fall back to the command name.
+var catalog = map[string]map[Method]string{
+	"rg":            {MethodCargo: "ripgrep", MethodBrew: "ripgrep", MethodConda: "ripgrep"},
+	"fd":            {MethodCargo: "fd-find", MethodConda: "fd-find"},
+	"bat":           {MethodCargo: "bat"},
+	"just":          {MethodCargo: "just"},
+	"yq":            {MethodGo: "github.com/mikefarah/yq/v4"},
+	"gopls":         {MethodGo: "golang.org/x/tools/gopls"},
+	"golangci-lint": {MethodGo: "github.com/golangci/golangci-lint/v2/cmd/golangci-lint"},
+	"goimports":     {MethodGo: "golang.org/x/tools/cmd/goimports"},
+	"shfmt":         {MethodGo: "mvdan.cc/sh/v3/cmd/shfmt"},
+	"black":         {MethodPip: "black"},
+	"ruff":          {MethodPip: "ruff"},
+	"flake8":        {MethodPip: "flake8"},
+	"poetry":        {MethodPip: "poetry"},
+	"pipenv":        {MethodPip: "pipenv"},
+	"http":          {MethodPip: "httpie", MethodBrew: "httpie", MethodConda: "httpie"},
+	"ansible":       {MethodPip: "ansible"},
+	"aws":           {MethodPip: "awscli", MethodBrew: "awscli", MethodConda: "awscli"},
+	"yarn":          {MethodNPM: "yarn"},
+	"pnpm":          {MethodNPM: "pnpm"},
+	"tsc":           {MethodNPM: "typescript"},
+	"eslint":        {MethodNPM: "eslint"},
+	"prettier":      {MethodNPM: "prettier"},
+	"node":          {MethodBrew: "node", MethodConda: "nodejs"},
+	"npm":           {MethodBrew: "node", MethodConda: "nodejs"},
+	"cmake":         {MethodPip: "cmake"},
+	"ninja":         {MethodPip: "ninja"},
+	"pre-commit":    {MethodPip: "pre-commit"},
+}
+
+func Package(command string, method Method) string {
+	if pkg, ok := catalog[command][method]; ok {
+		return pkg
+	}
+	if method == MethodBrew || method == MethodConda {
+		return command
+	}
+	return ""
+}
+
+func BinDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".autofix", "bin"), nil
+}
+
+// downloadTimeout bounds a whole binary download, so that a stalled
+// server cannot hang the fix.
+const downloadTimeout = 5 * time.Minute
+
+func Fetch(url, checksum, member, dest string) error {
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, checksum) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", url, checksum, got)
+	}
+
+	if strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz") {
+		if member == "" {
+			member = filepath.Base(dest)
+		}
+		data, err = extract(data, member)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0755)
+}
+
+func extract(archive []byte, member string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in archive", member)
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(header.Name, "./")
+		if header.Typeflag == tar.TypeReg && (name == member || filepath.Base(name) == member) {
+			return io.ReadAll(tr)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package fixengine_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/prompt"
+	"github.com/autofix/cli/internal/recipes"
+)
+
+const allowedRecipes = `
+recipes:
+  - name: run-elsewhere
+    match: {error_type: missing_command}
+    fix:
+      type: replacement
+      risk_level: low
+      steps: [{command: "docker run --rm sl"}]
+  - name: install
+    match: {error_type: missing_command}
+    fix:
+      type: preparation
+      risk_level: low
+      steps: [{command: "sudo apt-get install -y sl"}]
+`
+
+func TestAllowedFixesSkipDisallowedCandidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		want    fixengine.Outcome
+		ran     string
+	}{
+		{name: "first allowed", allowed: []string{"replacement"}, want: fixengine.OutcomeFixed, ran: "docker run --rm sl"},
+		{name: "later candidate", allowed: []string{"preparation"}, want: fixengine.OutcomeFixed, ran: "sudo apt-get install -y sl"},
+		{name: "none allowed", allowed: []string{"environment"}, want: fixengine.OutcomeNoFix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			environment := setup(t)
+			list, err := recipes.Parse([]byte(allowedRecipes), "test.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ex := &scripted{script: func(command string, ran []string) *executor.Result {
+				switch {
+				case command == "sl" && len(ran) > 0 && strings.Contains(ran[len(ran)-1], "apt-get install"):
+					return &executor.Result{}
+				case command == "sl":
+					return &executor.Result{ExitCode: 127, Stderr: "bash: sl: command not found\n"}
+				case strings.Contains(command, "sl"):
+					return &executor.Result{}
+				}
+				return &executor.Result{ExitCode: 1}
+			}}
+
+			f := fixengine.New(environment, nil, ex)
+			f.Dir = t.TempDir()
+			f.Prompter = prompt.AlwaysYes{}
+			f.Recipes = list
+			f.AllowedFixes = tt.allowed
+			f.ExecuteWithRetry("sl")
+			if f.Session.Outcome != tt.want {
+				t.Errorf("outcome = %s, want %s (ran %q)", f.Session.Outcome, tt.want, ex.ran)
+			}
+			if tt.ran != "" && (len(ex.ran) < 2 || ex.ran[1] != tt.ran) {
+				t.Errorf("ran %q, want fix %q", ex.ran, tt.ran)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package fixengine_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/autofix/cli/internal/cassette"
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/prompt"
+)
+
+// scripted stands in for the machine: it answers each command from a
+// script and reports itself local so that every strategy runs.
+type scripted struct {
+	ran    []string
+	script func(command string, ran []string) *executor.Result
+}
+
+func (s *scripted) Run(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+	result := s.script(req.Command, s.ran)
+	s.ran = append(s.ran, req.Command)
+	result.Command = req.Command
+	result.Success = result.ExitCode == 0
+	return result, nil
+}
+
+func (s *scripted) IsLocal() bool {
+	return true
+}
+
+type noLLM struct{}
+
+func (noLLM) GetSuggestion(req *llm.Request) (*llm.Suggestion, error) {
+	return nil, errors.New("no LLM in tests")
+}
+
+func setup(t *testing.T) *env.Environment {
+	t.Setenv("HOME", t.TempDir())
+	if err := config.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return &env.Environment{OS: env.OSDebian, PackageManager: env.PMApt, HasSudo: true}
+}
+
+// record runs command against the script and saves the session to a
+// cassette file, which it loads again.
+func record(t *testing.T, environment *env.Environment, command string, ex *scripted) (*cassette.Cassette, fixengine.Outcome) {
+	c := cassette.New(command, environment)
+	c.Dir = t.TempDir()
+	c.Retry = config.Get().Retry
+	recorder := cassette.NewRecorder(c)
+
+	f := fixengine.New(environment, recorder.LLMClient(noLLM{}), recorder.Executor(ex))
+	f.Dir = c.Dir
+	f.Prompter = recorder.Prompter(prompt.AlwaysYes{})
+	f.Host = recorder
+	f.ExecuteWithRetry(command)
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded, f.Session.Outcome
+}
+
+// replay runs the cassette's session again and checks that it used up
+// every recorded interaction.
+func replay(t *testing.T, c *cassette.Cassette) (*executor.Result, fixengine.Outcome) {
+	replayer := cassette.NewReplayer(c)
+	f := fixengine.New(c.Environment, replayer.LLMClient(), replayer.Executor())
+	f.Dir = c.Dir
+	f.Env = c.Env
+	f.Prompter = replayer.Prompter()
+	f.Host = replayer
+	f.Retry = c.Retry
+	f.Sleep = func(time.Duration) {}
+
+	result, _ := f.ExecuteWithRetry(c.Command)
+	if err := replayer.Err(); err != nil {
+		t.Fatalf("replay diverged: %v", err)
+	}
+	if remaining := replayer.Remaining(); remaining > 0 {
+		t.Fatalf("%d recorded interactions were not replayed", remaining)
+	}
+	return result, f.Session.Outcome
+}
+
+func TestReplayMissingCommand(t *testing.T) {
+	environment := setup(t)
+	installed := func(ran []string) bool {
+		for _, command := range ran {
+			if strings.Contains(command, "apt-get install") {
+				return true
+			}
+		}
+		return false
+	}
+	ex := &scripted{script: func(command string, ran []string) *executor.Result {
+		switch {
+		case command == "sl" && !installed(ran):
+			return &executor.Result{ExitCode: 127, Stderr: "bash: sl: command not found\n"}
+		case command == "sl", strings.Contains(command, "apt-get install"):
+			return &executor.Result{}
+		}
+		return &executor.Result{ExitCode: 1}
+	}}
+
+	c, recorded := record(t, environment, "sl", ex)
+	if recorded != fixengine.OutcomeFixed {
+		t.Fatalf("recorded outcome = %s, want %s", recorded, fixengine.OutcomeFixed)
+	}
+	if !c.Local {
+		t.Error("cassette does not record that the session ran locally")
+	}
+
+	if _, replayed := replay(t, c); replayed != recorded {
+		t.Errorf("replayed outcome = %s, want %s", replayed, recorded)
+	}
+}
+
+func TestReplayPortInUseIsHermetic(t *testing.T) {
+	environment := setup(t)
+	busy := regexp.MustCompile(`--port 3000\b`)
+	ex := &scripted{script: func(command string, ran []string) *executor.Result {
+		if busy.MatchString(command) {
+			return &executor.Result{ExitCode: 1, Stderr: "Error: listen EADDRINUSE: address already in use :::3000\n"}
+		}
+		if strings.HasPrefix(command, "node server.js --port ") {
+			return &executor.Result{}
+		}
+		return &executor.Result{ExitCode: 1}
+	}}
+
+	c, recorded := record(t, environment, "node server.js --port 3000", ex)
+	if recorded != fixengine.OutcomeFixed {
+		t.Fatalf("recorded outcome = %s, want %s", recorded, fixengine.OutcomeFixed)
+	}
+	rerun := ex.ran[len(ex.ran)-1]
+	port := strings.TrimPrefix(rerun, "node server.js --port ")
+
+	// The free port found while recording is taken now; a replay that
+	// looked for one again would pick another and diverge.
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		t.Skipf("cannot occupy port %s: %v", port, err)
+	}
+	defer listener.Close()
+
+	result, replayed := replay(t, c)
+	if replayed != recorded {
+		t.Errorf("replayed outcome = %s, want %s", replayed, recorded)
+	}
+	if result == nil || result.Command != rerun {
+		t.Errorf("replayed final command = %v, want %q", result, rerun)
+	}
+}
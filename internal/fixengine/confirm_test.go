@@ -0,0 +1,62 @@
+package fixengine_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/prompt"
+)
+
+// editing answers "edit" to the fix confirmation, replaces the command
+// with edited, and says yes to everything else.
+type editing struct {
+	edited string
+}
+
+func (e *editing) Confirm(q *prompt.Question) (prompt.Answer, error) {
+	if q.Kind == prompt.KindExecuteFix {
+		return prompt.Edit, nil
+	}
+	return prompt.Yes, nil
+}
+
+func (e *editing) Edit(command string) (string, error) {
+	return e.edited, nil
+}
+
+func TestEditedFix(t *testing.T) {
+	tests := []struct {
+		name   string
+		edited string
+		want   fixengine.Outcome
+	}{
+		{"sudo edit runs", "sudo apt-get install -y --no-install-recommends sl", fixengine.OutcomeFixed},
+		{"destructive edit is blocked", "sudo rm -rf /", fixengine.OutcomeBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			environment := setup(t)
+			ex := &scripted{script: func(command string, ran []string) *executor.Result {
+				switch {
+				case command == "sl" && len(ran) > 0 && strings.Contains(ran[len(ran)-1], "apt-get install"):
+					return &executor.Result{}
+				case command == "sl":
+					return &executor.Result{ExitCode: 127, Stderr: "bash: sl: command not found\n"}
+				case strings.Contains(command, "apt-get install"):
+					return &executor.Result{}
+				}
+				return &executor.Result{ExitCode: 1}
+			}}
+
+			f := fixengine.New(environment, noLLM{}, ex)
+			f.Dir = t.TempDir()
+			f.Prompter = &editing{edited: tt.edited}
+			f.ExecuteWithRetry("sl")
+			if f.Session.Outcome != tt.want {
+				t.Errorf("outcome = %s, want %s (ran %q)", f.Session.Outcome, tt.want, ex.ran)
+			}
+		})
+	}
+}
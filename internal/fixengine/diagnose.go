@@ -0,0 +1,239 @@
+package fixengine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+)
+
+var binarySearchDirs = []string{
+	"/usr/local/sbin",
+	"/usr/local/bin",
+	"/usr/sbin",
+	"/usr/bin",
+	"/sbin",
+	"/bin",
+	"/usr/lib/ccache",
+	"/usr/local/go/bin",
+	"/snap/bin",
+	"/opt/homebrew/bin",
+	"/opt/homebrew/sbin",
+	"/opt/homebrew/opt/*/bin",
+	"/usr/local/opt/*/bin",
+	"/opt/*/bin",
+	"~/.local/bin",
+	"~/go/bin",
+	"~/.cargo/bin",
+	"~/.autofix/bin",
+}
+
+// diagnosisPlans looks for an existing installation of a missing command
+// before anything is installed, and fixes PATH or alternatives instead.
+func (f *FixEngine) diagnosisPlans(errorInfo *errorparser.ErrorInfo) []*FixPlan {
+	if errorInfo.Type != errorparser.ErrorTypeMissingCommand && errorInfo.Type != errorparser.ErrorTypeMissingCompiler {
+		return nil
+	}
+	command := errorInfo.Command
+	if command == "" {
+		return nil
+	}
+	if !executor.IsLocal(f.Executor) {
+		return nil
+	}
+
+	if path := f.findOutsidePath(command); path != "" {
+		dir := filepath.Dir(path)
+		diagnosis := fmt.Sprintf("%s is installed at %s but PATH is missing %s", command, path, dir)
+		f.notice("Diagnosis", "%s", diagnosis)
+		plan := &FixPlan{
+			Type:        FixTypeEnvironment,
+			Source:      SourceDeterministic,
+			RiskLevel:   llm.RiskLow,
+			Explanation: diagnosis,
+		}
+		f.prependPath(plan, dir)
+		return []*FixPlan{plan}
+	}
+
+	if !f.packageInstalled(command) {
+		return nil
+	}
+
+	plans := []*FixPlan{}
+	diagnosis := fmt.Sprintf("package %s is installed but %s is not on PATH", command, command)
+	if target, broken := brokenLink(command); broken {
+		diagnosis = fmt.Sprintf("package %s is installed but %s points to missing %s", command, command, target)
+	}
+
+	if plan := f.alternativesPlan(command); plan != nil {
+		plan.Explanation = diagnosis + "; " + plan.Explanation
+		plans = append(plans, plan)
+	}
+	if reinstall := f.asUser(f.Environment.PackageManager.ReinstallCommand(command)); reinstall != "" {
+		plans = append(plans, &FixPlan{
+			Steps:       []Step{NewStep(reinstall, "", llm.RiskLow)},
+			Type:        FixTypePreparation,
+			Source:      SourceDeterministic,
+			RiskLevel:   llm.RiskLow,
+			Explanation: diagnosis + "; reinstall it",
+		})
+	}
+	if len(plans) > 0 {
+		f.notice("Diagnosis", "%s", diagnosis)
+	}
+	return plans
+}
+
+func (f *FixEngine) packageInstalled(pkg string) bool {
+	query := f.Environment.PackageManager.QueryCommand(pkg)
+	if query == "" {
+		return false
+	}
+	result, err := f.run(query)
+	if err != nil || !result.Success {
+		return false
+	}
+	// dpkg -s succeeds for removed packages whose config files remain.
+	if f.Environment.PackageManager == env.PMApt {
+		return strings.Contains(result.Stdout, "Status: install ok installed")
+	}
+	return true
+}
+
+func (f *FixEngine) findOutsidePath(command string) string {
+	path := f.lookupEnv("PATH")
+	home, _ := os.UserHomeDir()
+
+	for _, pattern := range binarySearchDirs {
+		if strings.HasPrefix(pattern, "~/") {
+			if home == "" {
+				continue
+			}
+			pattern = filepath.Join(home, pattern[2:])
+		}
+		dirs, _ := filepath.Glob(pattern)
+		for _, dir := range dirs {
+			if containsPath(path, dir) {
+				continue
+			}
+			candidate := filepath.Join(dir, command)
+			if isExecutable(candidate) {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+func (f *FixEngine) alternativesPlan(command string) *FixPlan {
+	versions := versionedBinaries(command)
+	if len(versions) == 0 {
+		return nil
+	}
+	best := versions[len(versions)-1]
+
+	switch f.Environment.PackageManager {
+	case env.PMApt, env.PMDnf, env.PMYum:
+		link := filepath.Join("/usr/bin", command)
+		install := f.asUser(fmt.Sprintf("sudo update-alternatives --install %s %s %s 50", link, command, best))
+		rollback := f.asUser(fmt.Sprintf("sudo update-alternatives --remove %s %s", command, best))
+		if install == "" {
+			return nil
+		}
+		return &FixPlan{
+			Steps:       []Step{NewStep(install, rollback, llm.RiskMedium)},
+			Type:        FixTypePreparation,
+			Source:      SourceDeterministic,
+			RiskLevel:   llm.RiskMedium,
+			Explanation: fmt.Sprintf("register %s as %s with update-alternatives", best, command),
+		}
+	case env.PMBrew:
+		return &FixPlan{
+			Steps:       []Step{NewStep("brew link "+command, "brew unlink "+command, llm.RiskLow)},
+			Type:        FixTypePreparation,
+			Source:      SourceDeterministic,
+			RiskLevel:   llm.RiskLow,
+			Explanation: "link the keg-only formula into the brew prefix",
+		}
+	default:
+		return nil
+	}
+}
+
+// versionedBinaries lists installed versions of command, such as
+// python3.11 or clang-15, oldest first. Other names that merely start with
+// the command, like python3-config, are not versions.
+func versionedBinaries(command string) []string {
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(command) + `-?([0-9]+(?:\.[0-9]+)*)$`)
+	type binary struct {
+		path    string
+		version []int
+	}
+	found := []binary{}
+	for _, dir := range []string{"/usr/bin", "/usr/local/bin"} {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			m := pattern.FindStringSubmatch(entry.Name())
+			path := filepath.Join(dir, entry.Name())
+			if m == nil || !isExecutable(path) {
+				continue
+			}
+			version := []int{}
+			for _, part := range strings.Split(m[1], ".") {
+				n, _ := strconv.Atoi(part)
+				version = append(version, n)
+			}
+			found = append(found, binary{path, version})
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool {
+		return compareVersions(found[a].version, found[b].version) < 0
+	})
+
+	versions := make([]string, len(found))
+	for i, b := range found {
+		versions[i] = b.path
+	}
+	return versions
+}
+
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
+}
+
+func brokenLink(command string) (string, bool) {
+	for _, dir := range []string{"/usr/bin", "/usr/local/bin", "/bin"} {
+		path := filepath.Join(dir, command)
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		target, _ := filepath.EvalSymlinks(path)
+		if target == "" {
+			target, _ = os.Readlink(path)
+		}
+		return target, true
+	}
+	return "", false
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
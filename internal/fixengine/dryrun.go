@@ -0,0 +1,64 @@
+package fixengine
+
+import (
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/safety"
+)
+
+type DryRun struct {
+	Command      string                 `json:"command"`
+	Result       *executor.Result       `json:"result"`
+	ErrorInfo    *errorparser.ErrorInfo `json:"error_info,omitempty"`
+	Plan         *FixPlan               `json:"plan,omitempty"`
+	RequiresSudo bool                   `json:"requires_sudo"`
+	NeedsConfirm bool                   `json:"needs_confirm"`
+	SafetyError  string                 `json:"safety_error,omitempty"`
+}
+
+func (f *FixEngine) DryRun(command string, result *executor.Result) (*DryRun, error) {
+	if result == nil {
+		var err error
+		result, err = f.run(command)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &DryRun{Command: command, Result: result}
+	if result.Success {
+		return plan, nil
+	}
+
+	plan.ErrorInfo = errorparser.Parse(result.Stderr, result.ExitCode)
+
+	fixPlan, err := f.resolvePlan(plan.ErrorInfo, command, result.Stderr, 0, nil)
+	if err != nil {
+		return plan, err
+	}
+	if fixPlan == nil || len(fixPlan.Steps) == 0 {
+		return plan, nil
+	}
+	plan.Plan = fixPlan
+
+	cfg := config.Get()
+	plan.NeedsConfirm = !cfg.Safety.AutoExecute
+	plan.RequiresSudo = fixPlan.RequiresSudo()
+	if plan.RequiresSudo && cfg.Safety.RequireSudoConfirm {
+		plan.NeedsConfirm = true
+	}
+
+	// Sudo is reported through RequiresSudo and confirmed when the fix
+	// runs; the safety verdict is the same blocklist check executePlan
+	// applies.
+	validator := safety.NewValidator()
+	for _, fixCmd := range fixPlan.Commands() {
+		if err := validator.CheckDestructive(fixCmd); err != nil {
+			plan.SafetyError = err.Error()
+			break
+		}
+	}
+
+	return plan, nil
+}
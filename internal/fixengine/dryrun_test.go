@@ -0,0 +1,55 @@
+package fixengine_test
+
+import (
+	"testing"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+	"github.com/autofix/cli/internal/llm"
+)
+
+// suggesting is an LLM client that always proposes the same command.
+type suggesting struct {
+	command string
+}
+
+func (s suggesting) GetSuggestion(req *llm.Request) (*llm.Suggestion, error) {
+	return &llm.Suggestion{FixType: fixengine.FixTypePreparation, RiskLevel: llm.RiskLow, Steps: []llm.SuggestionStep{{Command: s.command}}}, nil
+}
+
+func TestDryRunSafetyVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		fix     string
+		sudo    bool
+		blocked bool
+	}{
+		{name: "sudo install", fix: "sudo apt-get install -y sl", sudo: true},
+		{name: "destructive", fix: "sudo rm -rf /", sudo: true, blocked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			environment := setup(t)
+			ex := &scripted{script: func(command string, ran []string) *executor.Result {
+				return &executor.Result{ExitCode: 1}
+			}}
+			f := fixengine.New(environment, suggesting{tt.fix}, ex)
+			f.Dir = t.TempDir()
+
+			failed := &executor.Result{ExitCode: 1, Stderr: "something unusual went wrong\n"}
+			plan, err := f.DryRun("make", failed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.Plan == nil {
+				t.Fatal("no fix resolved")
+			}
+			if plan.RequiresSudo != tt.sudo {
+				t.Errorf("requires sudo = %v, want %v", plan.RequiresSudo, tt.sudo)
+			}
+			if blocked := plan.SafetyError != ""; blocked != tt.blocked {
+				t.Errorf("safety error = %q, want blocked %v", plan.SafetyError, tt.blocked)
+			}
+		})
+	}
+}
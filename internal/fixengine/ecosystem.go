@@ -0,0 +1,163 @@
+package fixengine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fallback"
+	"github.com/autofix/cli/internal/llm"
+)
+
+type ecosystem struct {
+	Name    string
+	Method  fallback.Method
+	Markers []string
+}
+
+var ecosystems = []ecosystem{
+	{Name: "node", Method: fallback.MethodNPM, Markers: []string{"package.json"}},
+	{Name: "python", Method: fallback.MethodPip, Markers: []string{"pyproject.toml", "requirements.txt", "setup.py", "Pipfile"}},
+	{Name: "rust", Method: fallback.MethodCargo, Markers: []string{"Cargo.toml"}},
+	{Name: "go", Method: fallback.MethodGo, Markers: []string{"go.mod"}},
+}
+
+func (f *FixEngine) projectDir() string {
+	dir := f.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+func (f *FixEngine) ecosystemPlans(errorInfo *errorparser.ErrorInfo, command string) []*FixPlan {
+	if errorInfo.Type != errorparser.ErrorTypeMissingCommand || errorInfo.Command == "" {
+		return nil
+	}
+	if !executor.IsLocal(f.Executor) {
+		return nil
+	}
+
+	dir := f.projectDir()
+	plans := []*FixPlan{}
+	for _, eco := range ecosystems {
+		marker := firstFile(dir, eco.Markers...)
+		if marker == "" {
+			continue
+		}
+		pkg := fallback.Package(errorInfo.Command, eco.Method)
+		if pkg == "" {
+			continue
+		}
+
+		var plan *FixPlan
+		switch eco.Method {
+		case fallback.MethodNPM:
+			plan = f.nodePlan(dir, pkg)
+		case fallback.MethodPip:
+			plan = f.pythonPlan(dir, pkg, command)
+		default:
+			if home, err := os.UserHomeDir(); err == nil {
+				plan = f.userInstallPlan(eco.Method, errorInfo.Command, home)
+			}
+		}
+		if plan == nil {
+			continue
+		}
+		plan.Explanation = fmt.Sprintf("%s project (%s): %s, rather than installing %s with the system package manager (%s)",
+			eco.Name, marker, planSummary(plan), errorInfo.Command, f.Environment.PackageManager)
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+func (f *FixEngine) nodePlan(dir, pkg string) *FixPlan {
+	var install, rollback string
+	switch {
+	case firstFile(dir, "pnpm-lock.yaml") != "" && hasTool("pnpm"):
+		install, rollback = "pnpm add -D "+pkg, "pnpm remove "+pkg
+	case firstFile(dir, "yarn.lock") != "" && hasTool("yarn"):
+		install, rollback = "yarn add -D "+pkg, "yarn remove "+pkg
+	case firstFile(dir, "bun.lockb", "bun.lock") != "" && hasTool("bun"):
+		install, rollback = "bun add -d "+pkg, "bun remove "+pkg
+	case hasTool("npm"):
+		install, rollback = "npm install --save-dev "+pkg, "npm uninstall "+pkg
+	default:
+		return nil
+	}
+
+	plan := &FixPlan{
+		Steps:     []Step{NewStep(install, rollback, llm.RiskLow)},
+		Type:      FixTypePreparation,
+		Source:    SourceDeterministic,
+		RiskLevel: llm.RiskLow,
+	}
+	f.prependPath(plan, filepath.Join(dir, "node_modules", ".bin"))
+	return plan
+}
+
+func (f *FixEngine) pythonPlan(dir, pkg, command string) *FixPlan {
+	tool := func(install, rollback, run string) *FixPlan {
+		return &FixPlan{
+			Steps: []Step{
+				NewStep(install, rollback, llm.RiskLow),
+				NewStep(run+" "+command, "", llm.RiskLow),
+			},
+			Type:      FixTypeReplacement,
+			Source:    SourceDeterministic,
+			RiskLevel: llm.RiskLow,
+		}
+	}
+
+	switch {
+	case firstFile(dir, "poetry.lock") != "" && hasTool("poetry"):
+		return tool("poetry add --group dev "+pkg, "poetry remove --group dev "+pkg, "poetry run")
+	case firstFile(dir, "uv.lock") != "" && hasTool("uv"):
+		return tool("uv add --dev "+pkg, "uv remove --dev "+pkg, "uv run")
+	}
+
+	for _, venv := range []string{".venv", "venv"} {
+		pip := filepath.Join(dir, venv, "bin", "pip")
+		if _, err := os.Stat(pip); err != nil {
+			continue
+		}
+		plan := &FixPlan{
+			Steps:     []Step{NewStep(pip+" install "+pkg, pip+" uninstall -y "+pkg, llm.RiskLow)},
+			Type:      FixTypePreparation,
+			Source:    SourceDeterministic,
+			RiskLevel: llm.RiskLow,
+		}
+		f.prependPath(plan, filepath.Dir(pip))
+		return plan
+	}
+	return nil
+}
+
+func (f *FixEngine) prependPath(plan *FixPlan, dir string) {
+	if path := f.lookupEnv("PATH"); !containsPath(path, dir) {
+		plan.Steps = append(plan.Steps, Step{Env: []string{"PATH=" + dir + string(filepath.ListSeparator) + path}, RiskLevel: llm.RiskLow})
+	}
+}
+
+func planSummary(plan *FixPlan) string {
+	commands := plan.Commands()
+	if len(commands) == 0 {
+		return ""
+	}
+	return strings.Join(commands, ", then ")
+}
+
+func firstFile(dir string, names ...string) string {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package fixengine
+
+import (
+	"fmt"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/ensure"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+)
+
+// EnsurePlan builds the plan that installs or upgrades an unmet
+// requirement, with the strategy ensure.Strategy picks for it.
+func (f *FixEngine) EnsurePlan(status *ensure.Status) (*FixPlan, error) {
+	r := status.Requirement
+	strategy := ensure.Strategy(r)
+	actions, err := ensure.Actions(r, strategy, f.Environment.PackageManager, status.Installed)
+	if err != nil {
+		return nil, err
+	}
+
+	verb := "install"
+	if status.Installed {
+		verb = "upgrade"
+	}
+	plan := &FixPlan{
+		Type:        FixTypePreparation,
+		Source:      SourceDeterministic,
+		RiskLevel:   llm.RiskLow,
+		Explanation: fmt.Sprintf("%s %s with %s", verb, r, strategy),
+	}
+	if strategy == ensure.ViaPackage && r.Op != "" {
+		plan.Explanation += fmt.Sprintf("; %s only has the version in its repositories, which may not satisfy %s%s", f.Environment.PackageManager, r.Op, r.Version)
+	}
+	for _, action := range actions {
+		command := f.asUser(action.Command)
+		if command == "" {
+			return nil, fmt.Errorf("installing %s needs root or sudo", r.Name)
+		}
+		step := NewStep(command, f.asUser(action.Rollback), llm.RiskLow)
+		if step.RequiresSudo {
+			plan.RiskLevel = llm.RiskMedium
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, nil
+}
+
+// Apply proposes a plan built outside a session and runs it once it is
+// confirmed, journaling every command like any other fix.
+func (f *FixEngine) Apply(plan *FixPlan) (*executor.Result, error) {
+	f.emit(events.FixProposed{Fix: plan.Event()})
+	proposed := plan
+	if !config.Get().Safety.AutoExecute {
+		confirmed, err := f.confirmPlan(plan)
+		if err != nil {
+			return nil, err
+		}
+		if confirmed == nil {
+			return nil, stop(OutcomeDeclined, "fix declined by user")
+		}
+		plan = confirmed
+	}
+	f.emit(events.FixConfirmed{Fix: plan.Event(), Edited: plan != proposed})
+
+	result, err := f.executePlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	f.emit(events.FixApplied{Fix: plan.Event(), Result: result})
+	return result, nil
+}
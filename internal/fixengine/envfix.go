@@ -0,0 +1,161 @@
+package fixengine
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+)
+
+var caBundlePaths = []string{
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/ca-bundle.pem",
+	"/etc/ssl/cert.pem",
+	"/opt/homebrew/etc/ca-certificates/cert.pem",
+	"/usr/local/etc/ca-certificates/cert.pem",
+}
+
+var pkgConfigDirs = []string{
+	"/usr/lib/pkgconfig",
+	"/usr/lib64/pkgconfig",
+	"/usr/share/pkgconfig",
+	"/usr/local/lib/pkgconfig",
+	"/usr/local/share/pkgconfig",
+	"/usr/lib/*/pkgconfig",
+	"/opt/homebrew/lib/pkgconfig",
+	"/opt/homebrew/opt/*/lib/pkgconfig",
+	"/usr/local/opt/*/lib/pkgconfig",
+}
+
+// getEnvFix finds variables for the error on this machine: CA bundles,
+// JDKs and .pc files on its filesystem, proxies from its configuration.
+// None of it applies to a remote host.
+func (f *FixEngine) getEnvFix(errorInfo *errorparser.ErrorInfo) []string {
+	if !executor.IsLocal(f.Executor) {
+		return nil
+	}
+	switch errorInfo.Type {
+	case errorparser.ErrorTypeCertificate:
+		return f.caBundleEnv()
+	case errorparser.ErrorTypeJavaHome:
+		return f.javaHomeEnv()
+	case errorparser.ErrorTypePkgConfig:
+		return f.pkgConfigEnv(errorInfo.Package)
+	case errorparser.ErrorTypeNetwork:
+		return f.proxyEnv()
+	default:
+		return nil
+	}
+}
+
+func (f *FixEngine) caBundleEnv() []string {
+	bundle := config.Get().Network.CABundle
+	if bundle == "" {
+		for _, path := range caBundlePaths {
+			if _, err := os.Stat(path); err == nil {
+				bundle = path
+				break
+			}
+		}
+	}
+	if bundle == "" || f.lookupEnv("SSL_CERT_FILE") == bundle {
+		return nil
+	}
+	return []string{
+		"SSL_CERT_FILE=" + bundle,
+		"REQUESTS_CA_BUNDLE=" + bundle,
+		"NODE_EXTRA_CA_CERTS=" + bundle,
+		"CURL_CA_BUNDLE=" + bundle,
+	}
+}
+
+func (f *FixEngine) javaHomeEnv() []string {
+	home := findJavaHome()
+	if home == "" || f.lookupEnv("JAVA_HOME") == home {
+		return nil
+	}
+	return []string{"JAVA_HOME=" + home}
+}
+
+func findJavaHome() string {
+	if runtime.GOOS == "darwin" {
+		if output, err := exec.Command("/usr/libexec/java_home").Output(); err == nil {
+			return strings.TrimSpace(string(output))
+		}
+	}
+
+	if path, err := exec.LookPath("java"); err == nil {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			home := filepath.Dir(filepath.Dir(resolved))
+			if filepath.Base(home) == "jre" {
+				home = filepath.Dir(home)
+			}
+			return home
+		}
+	}
+
+	if _, err := os.Stat("/usr/lib/jvm/default-java"); err == nil {
+		return "/usr/lib/jvm/default-java"
+	}
+	return ""
+}
+
+func (f *FixEngine) pkgConfigEnv(pkg string) []string {
+	if pkg == "" {
+		return nil
+	}
+
+	existing := f.lookupEnv("PKG_CONFIG_PATH")
+	for _, pattern := range pkgConfigDirs {
+		matches, _ := filepath.Glob(filepath.Join(pattern, pkg+".pc"))
+		for _, match := range matches {
+			dir := filepath.Dir(match)
+			if containsPath(existing, dir) {
+				continue
+			}
+			if existing != "" {
+				return []string{"PKG_CONFIG_PATH=" + dir + string(os.PathListSeparator) + existing}
+			}
+			return []string{"PKG_CONFIG_PATH=" + dir}
+		}
+	}
+	return nil
+}
+
+func (f *FixEngine) proxyEnv() []string {
+	network := config.Get().Network
+	vars := []string{}
+	add := func(key, value string) {
+		if value != "" && f.lookupEnv(key) != value {
+			vars = append(vars, key+"="+value, strings.ToLower(key)+"="+value)
+		}
+	}
+	add("HTTP_PROXY", network.HTTPProxy)
+	add("HTTPS_PROXY", network.HTTPSProxy)
+	add("NO_PROXY", network.NoProxy)
+	return vars
+}
+
+func (f *FixEngine) lookupEnv(key string) string {
+	for i := len(f.Env) - 1; i >= 0; i-- {
+		if name, value, ok := strings.Cut(f.Env[i], "="); ok && name == key {
+			return value
+		}
+	}
+	return os.Getenv(key)
+}
+
+func containsPath(list, dir string) bool {
+	for _, entry := range filepath.SplitList(list) {
+		if entry == dir {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package fixengine
+
+import (
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+)
+
+type Explanation struct {
+	DryRun
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Evidence    []string `json:"evidence,omitempty"`
+	Cause       string   `json:"likely_cause,omitempty"`
+}
+
+// Explain classifies an already captured failure and resolves the fix
+// that would be proposed for it, without running anything.
+func (f *FixEngine) Explain(command string, result *executor.Result) (*Explanation, error) {
+	plan, err := f.DryRun(command, result)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &Explanation{DryRun: *plan}
+	if info := plan.ErrorInfo; info != nil {
+		explanation.Fingerprint = errorparser.Fingerprint(info, result.Stderr)
+		explanation.Evidence = errorparser.Evidence(result.Stderr, info)
+		explanation.Cause = errorparser.Cause(info)
+	}
+	return explanation, nil
+}
@@ -0,0 +1,206 @@
+package fixengine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fallback"
+	"github.com/autofix/cli/internal/llm"
+)
+
+func (f *FixEngine) privileged() bool {
+	return f.Environment.HasSudo || f.Environment.IsRoot
+}
+
+// asUser adapts a package manager command to the current user: root runs
+// it without sudo, and without sudo access it cannot run at all.
+func (f *FixEngine) asUser(command string) string {
+	if command == "" || !isSudoCommand(command) {
+		return command
+	}
+	if f.Environment.IsRoot {
+		return strings.TrimSpace(strings.TrimPrefix(command, "sudo"))
+	}
+	if !f.Environment.HasSudo {
+		return ""
+	}
+	return command
+}
+
+func (f *FixEngine) sudoFreePlans(errorInfo *errorparser.ErrorInfo) []*FixPlan {
+	if errorInfo.Type != errorparser.ErrorTypeMissingCommand || errorInfo.Command == "" || f.privileged() {
+		return nil
+	}
+	if !executor.IsLocal(f.Executor) {
+		return nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	cfg := config.Get()
+	order := cfg.Fallback.Order
+	if len(order) == 0 {
+		order = fallback.DefaultOrder
+	}
+
+	plans := []*FixPlan{}
+	for _, method := range order {
+		if plan := f.userInstallPlan(fallback.Method(method), errorInfo.Command, home); plan != nil {
+			plans = append(plans, plan)
+		}
+	}
+	return plans
+}
+
+func (f *FixEngine) userInstallPlan(method fallback.Method, command, home string) *FixPlan {
+	pkg := fallback.Package(command, method)
+	local := filepath.Join(home, ".local")
+
+	var install, rollback, binDir, written string
+	switch method {
+	case fallback.MethodPip:
+		if pkg == "" {
+			return nil
+		}
+		if hasTool("pipx") {
+			install, rollback = "pipx install "+pkg, "pipx uninstall "+pkg
+		} else if pip := firstTool("pip3", "pip"); pip != "" {
+			install, rollback = pip+" install --user "+pkg, pip+" uninstall -y "+pkg
+		}
+		binDir = filepath.Join(local, "bin")
+	case fallback.MethodNPM:
+		if pkg == "" || !hasTool("npm") {
+			return nil
+		}
+		install = fmt.Sprintf("npm install -g --prefix %s %s", local, pkg)
+		rollback = fmt.Sprintf("npm uninstall -g --prefix %s %s", local, pkg)
+		binDir = filepath.Join(local, "bin")
+	case fallback.MethodCargo:
+		if pkg == "" || !hasTool("cargo") {
+			return nil
+		}
+		install, rollback = "cargo install "+pkg, "cargo uninstall "+pkg
+		binDir = filepath.Join(home, ".cargo", "bin")
+	case fallback.MethodGo:
+		if pkg == "" || !hasTool("go") {
+			return nil
+		}
+		binDir = f.lookupEnv("GOBIN")
+		if binDir == "" {
+			gopath := f.lookupEnv("GOPATH")
+			if gopath == "" {
+				gopath = filepath.Join(home, "go")
+			}
+			binDir = filepath.Join(gopath, "bin")
+		}
+		install = "go install " + pkg + "@latest"
+		written = filepath.Join(binDir, command)
+		rollback = "rm " + written
+	case fallback.MethodBrew:
+		brew := firstTool("brew", "/home/linuxbrew/.linuxbrew/bin/brew", filepath.Join(home, ".linuxbrew", "bin", "brew"))
+		if brew == "" {
+			return nil
+		}
+		install, rollback = brew+" install "+pkg, brew+" uninstall "+pkg
+		binDir = filepath.Dir(brew)
+		if brew == "brew" {
+			binDir = ""
+		}
+	case fallback.MethodConda:
+		conda := firstTool("micromamba", "mamba", "conda")
+		if conda == "" {
+			return nil
+		}
+		prefix := filepath.Join(home, ".autofix", "conda")
+		verb := "install"
+		if _, err := os.Stat(prefix); os.IsNotExist(err) {
+			verb = "create"
+		}
+		install = fmt.Sprintf("%s %s -y -p %s -c conda-forge %s", conda, verb, prefix, pkg)
+		rollback = fmt.Sprintf("%s remove -y -p %s %s", conda, prefix, pkg)
+		binDir = filepath.Join(prefix, "bin")
+	case fallback.MethodBinary:
+		if findBinary(command) == nil {
+			return nil
+		}
+		self, err := os.Executable()
+		if err != nil {
+			return nil
+		}
+		binDir, err = fallback.BinDir()
+		if err != nil {
+			return nil
+		}
+		install = self + " install-binary " + command
+		written = filepath.Join(binDir, command)
+		rollback = "rm " + written
+	}
+	if install == "" {
+		return nil
+	}
+
+	step := NewStep(install, rollback, llm.RiskLow)
+	if written != "" {
+		step.Files = []string{written}
+	}
+	plan := &FixPlan{
+		Steps:       []Step{step},
+		Type:        FixTypePreparation,
+		Source:      SourceDeterministic,
+		RiskLevel:   llm.RiskLow,
+		Explanation: fmt.Sprintf("install %s without sudo using %s", command, method),
+	}
+	if binDir != "" {
+		f.prependPath(plan, binDir)
+	}
+	return plan
+}
+
+func findBinary(name string) *config.Binary {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	for i, b := range config.Get().Fallback.Binaries {
+		if b.Name == name && (b.Platform == "" || b.Platform == platform) {
+			return &config.Get().Fallback.Binaries[i]
+		}
+	}
+	return nil
+}
+
+func FetchBinary(name string) (string, error) {
+	binary := findBinary(name)
+	if binary == nil {
+		return "", fmt.Errorf("no binary configured for %s on %s/%s", name, runtime.GOOS, runtime.GOARCH)
+	}
+	if binary.SHA256 == "" {
+		return "", fmt.Errorf("binary %s has no sha256 checksum configured", name)
+	}
+	dir, err := fallback.BinDir()
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, name)
+	return dest, fallback.Fetch(binary.URL, binary.SHA256, binary.Path, dest)
+}
+
+func hasTool(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func firstTool(names ...string) string {
+	for _, name := range names {
+		if hasTool(name) {
+			return name
+		}
+	}
+	return ""
+}
@@ -1,111 +1,487 @@
 package fixengine
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/dotenv"
 	"github.com/autofix/cli/internal/env"
 	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/events"
 	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/journal"
+	"github.com/autofix/cli/internal/kb"
 	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/prompt"
+	"github.com/autofix/cli/internal/recipes"
+	"github.com/autofix/cli/internal/safety"
 )
 
-const MaxRetries = 3
+const (
+	FixTypeReplacement = "replacement"
+	FixTypePreparation = "preparation"
+	FixTypeEnvironment = "environment"
+)
+
+const (
+	SourceDeterministic = "deterministic"
+	SourceLLM           = "llm"
+)
 
 type FixEngine struct {
 	Environment *env.Environment
 	LLMClient   llm.Client
+	Executor    executor.Executor
+	Sandbox     *executor.Sandbox
+	Prompter    prompt.Prompter
+	Journal     *journal.Journal
+	Session     *Session
+	KB          *kb.Store
+	Recipes     []*recipes.Recipe
+	Retry       config.Retry
+	Events      *events.Bus
+	Host        Host
+	Sleep       func(time.Duration)
+	Dir         string
+	Env         []string
+	EnvFile     string
+	// Captured, when set, is the result of a run that already happened
+	// outside the engine; it stands in for the first attempt.
+	Captured *executor.Result
+	// AllowedFixes limits fixes to these types; empty allows every type.
+	AllowedFixes []string
+	// Check, when set, decides whether a run succeeded instead of its
+	// exit code. Its error is appended to stderr for classification.
+	Check func(result *executor.Result) error
 }
 
-func New(e *env.Environment, llmClient llm.Client) *FixEngine {
+func New(e *env.Environment, llmClient llm.Client, ex executor.Executor) *FixEngine {
 	return &FixEngine{
 		Environment: e,
 		LLMClient:   llmClient,
+		Executor:    ex,
+		Retry:       config.Get().Retry,
 	}
 }
 
-func (f *FixEngine) ExecuteWithRetry(command string, attempt int) (*executor.Result, error) {
-	args := strings.Fields(command)
-	if len(args) == 0 {
-		args = []string{"/bin/sh", "-c", command}
+func (f *FixEngine) ExecuteWithRetry(command string) (final *executor.Result, failure error) {
+	f.Session = NewSession()
+	f.Session.OnOutcome = f.learn
+	f.Session.events = f.Events
+	start := time.Now()
+	attempts, maxAttempts := 0, 0
+	fixed := false
+	defer func() {
+		outcome := OutcomeOf(final, failure, fixed)
+		f.Session.Outcome = outcome
+		finished := events.SessionFinished{
+			Command:  command,
+			Attempts: attempts,
+			Success:  failure == nil && final != nil && final.Success,
+			Outcome:  string(outcome),
+			ExitCode: outcome.ExitCode(),
+		}
+		if failure != nil {
+			finished.Error = failure.Error()
+		}
+		f.emit(finished)
+	}()
+
+	for attempt := 0; ; attempt++ {
+		attempts = attempt + 1
+		f.emit(events.AttemptStarted{Attempt: attempt, MaxAttempts: maxAttempts, Command: command})
+		result, err := f.Captured, error(nil)
+		if attempt > 0 || result == nil {
+			result, err = f.run(command)
+		}
+		if err != nil {
+			return result, err
+		}
+		f.check(result)
+		f.emit(events.CommandFinished{Attempt: attempt, Result: result})
+
+		if result.Success {
+			f.Session.succeeded()
+			return result, nil
+		}
+
+		errorInfo := errorparser.Parse(result.Stderr, result.ExitCode)
+		fingerprint := errorparser.Fingerprint(errorInfo, result.Stderr)
+		f.emit(events.ErrorClassified{Attempt: attempt, Fingerprint: fingerprint, Error: errorInfo})
+		record, err := f.Session.observe(fingerprint, errorInfo, attempt)
+		if err != nil {
+			return result, err
+		}
+
+		policy := f.Retry.For(string(errorInfo.Type))
+		maxAttempts = policy.MaxAttempts
+		if attempt >= policy.MaxAttempts {
+			return result, stop(OutcomeMaxRetries, "max retries exceeded (%d for %s errors)", policy.MaxAttempts, errorInfo.Type)
+		}
+		if policy.Budget > 0 && time.Since(start) >= policy.Budget {
+			return result, stop(OutcomeMaxRetries, "retry budget of %s exhausted", policy.Budget)
+		}
+
+		plan, err := f.getFix(errorInfo, command, result.Stderr, attempt, record)
+		if err != nil {
+			return result, err
+		}
+
+		// With a backoff policy an error without a fix is treated as
+		// transient and the command is simply run again.
+		if plan == nil && policy.Backoff == 0 {
+			if len(record.Tried) > 0 {
+				return result, stop(OutcomeNoFix, "no untried fix left for the %s error; already tried without effect: %s", record.Type, strings.Join(record.Tried, "; "))
+			}
+			return result, stop(OutcomeNoFix, "no fix available")
+		}
+
+		if plan != nil {
+			var edited bool
+			plan, edited, err = f.offer(plan, attempt, record, func() (*FixPlan, error) {
+				return f.getFix(errorInfo, command, result.Stderr, attempt, record)
+			})
+			if err != nil {
+				return result, err
+			}
+			f.emit(events.FixConfirmed{Fix: plan.Event(), Edited: edited})
+			f.Session.applying(record, plan)
+
+			fixResult, err := f.executePlan(plan)
+			if err != nil {
+				return result, err
+			}
+			fixed = true
+			f.emit(events.FixApplied{Fix: plan.Event(), Result: fixResult})
+
+			if plan.Type == FixTypeReplacement && fixResult != nil {
+				if f.check(fixResult); fixResult.Success {
+					f.Session.succeeded()
+				}
+				return fixResult, nil
+			}
+		}
+
+		if policy.Backoff > 0 {
+			delay := backoffDelay(policy.Backoff, attempt)
+			if policy.Budget > 0 && time.Since(start)+delay > policy.Budget {
+				return result, stop(OutcomeMaxRetries, "retry budget of %s exhausted", policy.Budget)
+			}
+			f.emit(events.BackoffStarted{Delay: delay})
+			f.sleep(delay)
+		}
+	}
+}
+
+// maxBackoff caps the doubling, which would otherwise overflow after a
+// few dozen attempts.
+const maxBackoff = 10 * time.Minute
+
+func backoffDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff
+	for i := 0; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
 	}
+	return min(delay, max(backoff, maxBackoff))
+}
+
+func (f *FixEngine) emit(e events.Event) {
+	f.Events.Emit(e)
+}
 
-	cmd := exec.Command(args[0], args[1:]...)
-	result, err := executor.Runner(cmd)
+func (f *FixEngine) notice(tag, format string, args ...any) {
+	f.emit(events.Notice{Tag: tag, Text: fmt.Sprintf(format, args...)})
+}
 
-	if result.Success {
-		return result, nil
+func (f *FixEngine) sleep(d time.Duration) {
+	if f.Sleep != nil {
+		f.Sleep(d)
+		return
 	}
+	time.Sleep(d)
+}
 
-	if attempt >= MaxRetries {
-		return result, fmt.Errorf("max retries exceeded")
+func (f *FixEngine) ask(q *prompt.Question) (prompt.Answer, error) {
+	if f.Prompter == nil {
+		return prompt.No, nil
 	}
+	return f.Prompter.Confirm(q)
+}
 
-	errorInfo := errorparser.Parse(result.Stderr, result.ExitCode)
-	fixCmd, fixType, err := f.GetFix(errorInfo, command, result.Stderr, attempt)
+func (f *FixEngine) confirm(q *prompt.Question) (bool, error) {
+	answer, err := f.ask(q)
+	return answer == prompt.Yes, err
+}
 
+// offer proposes plan and, unless fixes run without asking, confirms it.
+// A declined plan counts as tried and next supplies the one to offer
+// instead; once none is left the session ends as declined. It returns the
+// plan to apply and whether the user edited it.
+func (f *FixEngine) offer(plan *FixPlan, attempt int, record *ErrorRecord, next func() (*FixPlan, error)) (*FixPlan, bool, error) {
+	for {
+		f.emit(events.FixProposed{Attempt: attempt, Fix: plan.Event()})
+		if config.Get().Safety.AutoExecute {
+			return plan, false, nil
+		}
+		confirmed, err := f.confirmPlan(plan)
+		if err != nil {
+			return nil, false, err
+		}
+		if confirmed != nil {
+			return confirmed, confirmed != plan, nil
+		}
+
+		record.Tried = append(record.Tried, planKey(plan))
+		if plan, err = next(); err != nil {
+			return nil, false, err
+		}
+		if plan == nil {
+			return nil, false, stop(OutcomeDeclined, "fix declined by user")
+		}
+		f.notice("Declined", "offering the next fix instead")
+	}
+}
+
+// confirmPlan asks before a plan runs. It returns nil if the plan was
+// declined, or the plan with the user's edits applied.
+func (f *FixEngine) confirmPlan(plan *FixPlan) (*FixPlan, error) {
+	answer, err := f.ask(&prompt.Question{
+		Kind:     prompt.KindExecuteFix,
+		Text:     "Execute this fix?",
+		Commands: plan.Commands(),
+		Risk:     plan.RiskLevel,
+		Sudo:     plan.RequiresSudo(),
+		Editable: len(plan.Commands()) > 0,
+	})
+	if err != nil || answer == prompt.No {
+		return nil, err
+	}
+	if answer == prompt.Yes {
+		return plan, nil
+	}
+
+	edited := *plan
+	edited.Steps = make([]Step, len(plan.Steps))
+	validator := safety.NewValidator()
+	for i, step := range plan.Steps {
+		edited.Steps[i] = step
+		if step.Command == "" {
+			continue
+		}
+		command, err := f.Prompter.Edit(step.Command)
+		if err != nil {
+			return nil, err
+		}
+		if command == step.Command {
+			continue
+		}
+		// Sudo in an edit is confirmed when the step runs, like any other.
+		if err := validator.CheckDestructive(command); err != nil {
+			return nil, Blocked(fmt.Errorf("edited fix rejected: %w", err))
+		}
+		f.notice("Edited Fix", "%s", command)
+		replacement := NewStep(command, "", step.RiskLevel)
+		replacement.Precondition = step.Precondition
+		edited.Steps[i] = replacement
+	}
+	return &edited, nil
+}
+
+func (f *FixEngine) check(result *executor.Result) {
+	if f.Check == nil || result == nil {
+		return
+	}
+	err := f.Check(result)
+	result.Success = err == nil
 	if err != nil {
-		return result, err
+		result.Stderr += err.Error() + "\n"
+	}
+}
+
+func (f *FixEngine) allows(plan *FixPlan) bool {
+	if len(f.AllowedFixes) == 0 {
+		return true
+	}
+	for _, t := range f.AllowedFixes {
+		if t == plan.Type {
+			return true
+		}
 	}
+	return false
+}
 
-	if fixCmd == "" {
-		return result, fmt.Errorf("no fix available")
+// usable reports whether plan is a candidate: not tried before for this
+// error and of an allowed type.
+func (f *FixEngine) usable(plan *FixPlan, record *ErrorRecord) bool {
+	if record.alreadyTried(plan) {
+		return false
 	}
+	if !f.allows(plan) {
+		f.notice("Fix Not Allowed", "%s fix skipped; only %s fixes are allowed", plan.Type, strings.Join(f.AllowedFixes, ", "))
+		return false
+	}
+	return true
+}
 
-	fmt.Printf("[Applying Fix] %s\n", fixCmd)
+func (f *FixEngine) journalCommand(command, rollback string, result *executor.Result) {
+	if f.Journal == nil {
+		return
+	}
+	if err := f.Journal.RecordCommand(command, rollback, result.ExitCode, result.Stdout+result.Stderr, f.Environment.PackageManager); err != nil {
+		f.notice("Journal", "failed to record %s: %v", command, err)
+	}
+}
 
-	confirmed := true
-	cfg := config.Get()
+// backupStep saves what a step is about to change in the journal. The
+// paths are on this machine, so remote runs have nothing to back up.
+func (f *FixEngine) backupStep(step Step) error {
+	if f.Journal == nil || !executor.IsLocal(f.Executor) {
+		return nil
+	}
+	for _, path := range step.Files {
+		if err := f.Journal.BackupFile(path); err != nil {
+			return err
+		}
+	}
+	if len(step.Attrs) > 0 {
+		return f.Journal.BackupAttrs(step.Recursive, step.Attrs...)
+	}
+	return nil
+}
 
-	if !cfg.Safety.AutoExecute {
-		fmt.Print("Execute this fix? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		confirmed = (strings.ToLower(response) == "y")
+func (f *FixEngine) run(command string) (*executor.Result, error) {
+	return f.Executor.Run(context.Background(), &executor.Request{
+		Command: command,
+		Dir:     f.Dir,
+		Env:     f.Env,
+	})
+}
+
+func (f *FixEngine) offerDotenvSave(vars []string) error {
+	if !executor.IsLocal(f.Executor) && f.EnvFile == "" {
+		return nil
 	}
 
-	if !confirmed {
-		return result, fmt.Errorf("fix declined by user")
+	// PATH additions point at this machine's install locations and do not
+	// belong in a project .env.
+	kept := []string{}
+	for _, v := range vars {
+		if !strings.HasPrefix(v, "PATH=") {
+			kept = append(kept, v)
+		}
+	}
+	if vars = kept; len(vars) == 0 {
+		return nil
 	}
 
-	if isSudoCommand(fixCmd) && cfg.Safety.RequireSudoConfirm {
-		fmt.Print("This command requires sudo. Execute? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		confirmed = (strings.ToLower(response) == "y")
-		if !confirmed {
-			return result, fmt.Errorf("sudo command declined")
+	envFile := f.EnvFile
+	if envFile == "" {
+		envFile = filepath.Join(f.Dir, ".env")
+	}
+	save, err := f.confirm(&prompt.Question{Kind: prompt.KindSaveEnv, Text: fmt.Sprintf("Save these variables to %s?", envFile)})
+	if err != nil || !save {
+		return err
+	}
+	if failure := onHost(f, "save-env", func() string {
+		if err := f.saveDotenv(envFile, vars); err != nil {
+			return err.Error()
 		}
+		return ""
+	}); failure != "" {
+		return errors.New(failure)
 	}
+	return nil
+}
 
-	fixArgs := strings.Fields(fixCmd)
-	fixExec := exec.Command(fixArgs[0], fixArgs[1:]...)
-	fixResult, _ := executor.Runner(fixExec)
+func (f *FixEngine) saveDotenv(envFile string, vars []string) error {
+	if abs, err := filepath.Abs(envFile); err == nil {
+		envFile = abs
+	}
+	if f.Journal != nil {
+		if err := f.Journal.BackupFile(envFile); err != nil {
+			return fmt.Errorf("failed to write journal: %w", err)
+		}
+	}
 
-	if !fixResult.Success {
-		fmt.Printf("[Fix Failed] %s\n", fixResult.Stderr)
-		return result, fmt.Errorf("fix command failed: %s", fixResult.Stderr)
+	for _, v := range vars {
+		key, value, _ := strings.Cut(v, "=")
+		if f.Journal != nil {
+			previous, existed := dotenv.Lookup(envFile, key)
+			if err := f.Journal.RecordEnv(envFile, key, previous, existed); err != nil {
+				return fmt.Errorf("failed to write journal: %w", err)
+			}
+		}
+		if err := dotenv.Set(envFile, key, value); err != nil {
+			return fmt.Errorf("failed to save %s: %w", envFile, err)
+		}
 	}
+	return nil
+}
+
+func (f *FixEngine) GetFix(errorInfo *errorparser.ErrorInfo, originalCommand, stderr string, attempt int) (*FixPlan, error) {
+	return f.getFix(errorInfo, originalCommand, stderr, attempt, nil)
+}
 
-	if fixType == "replacement" {
-		fmt.Println("[Success]")
-		return fixResult, nil
+func (f *FixEngine) getFix(errorInfo *errorparser.ErrorInfo, originalCommand, stderr string, attempt int, record *ErrorRecord) (*FixPlan, error) {
+	plan, err := f.resolvePlan(errorInfo, originalCommand, stderr, attempt, record)
+	if err != nil || plan == nil || len(plan.Steps) == 0 {
+		return nil, err
 	}
 
-	fmt.Printf("[Retry %d/%d]\n", attempt+1, MaxRetries)
-	return f.ExecuteWithRetry(command, attempt+1)
+	approved, err := f.approvePlan(plan, originalCommand)
+	if err != nil || !approved {
+		return nil, err
+	}
+	return plan, nil
 }
 
-func (f *FixEngine) GetFix(errorInfo *errorparser.ErrorInfo, originalCommand, stderr string, attempt int) (string, string, error) {
-	deterministicFix := f.getDeterministicFix(errorInfo)
-	if deterministicFix != "" {
-		return deterministicFix, "preparation", nil
+func (f *FixEngine) resolvePlan(errorInfo *errorparser.ErrorInfo, originalCommand, stderr string, attempt int, record *ErrorRecord) (*FixPlan, error) {
+	if plan := onHost(f, "kb", func() *FixPlan { return f.knownFix(errorInfo, stderr, record) }); plan != nil {
+		return plan, nil
+	}
+
+	if plan := onHost(f, "recipes", func() *FixPlan { return f.recipeFix(errorInfo, originalCommand, stderr, record) }); plan != nil {
+		return plan, nil
+	}
+
+	if vars := onHost(f, "env", func() []string { return f.getEnvFix(errorInfo) }); len(vars) > 0 {
+		plan := &FixPlan{
+			Steps:     []Step{{Env: vars, RiskLevel: llm.RiskLow}},
+			Type:      FixTypeEnvironment,
+			Source:    SourceDeterministic,
+			RiskLevel: llm.RiskLow,
+		}
+		if f.usable(plan, record) {
+			return plan, nil
+		}
+	}
+
+	strategies := onHost(f, "strategies", func() []*FixPlan {
+		plans := append(f.portPlans(errorInfo, originalCommand), f.permissionPlans(errorInfo, originalCommand)...)
+		plans = append(plans, f.diagnosisPlans(errorInfo)...)
+		plans = append(plans, f.ecosystemPlans(errorInfo, originalCommand)...)
+		return append(plans, f.sudoFreePlans(errorInfo)...)
+	})
+	for _, plan := range strategies {
+		if f.usable(plan, record) {
+			return plan, nil
+		}
 	}
 
-	if attempt > 0 {
-		return "", "", nil
+	if plan := f.getDeterministicFix(errorInfo); plan != nil && f.usable(plan, record) {
+		return plan, nil
+	}
+
+	if policy := f.Retry.For(string(errorInfo.Type)); attempt > 0 && (policy.LLMOnRetry == nil || !*policy.LLMOnRetry) {
+		return nil, nil
+	}
+	if f.LLMClient == nil {
+		return nil, nil
 	}
 
 	llmReq := &llm.Request{
@@ -125,50 +501,91 @@ func (f *FixEngine) GetFix(errorInfo *errorparser.ErrorInfo, originalCommand, st
 
 	suggestion, err := f.LLMClient.GetSuggestion(llmReq)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+
+	plan := planFromSuggestion(suggestion)
+	f.emit(events.SuggestionReceived{Fix: plan.Event()})
+	if !f.usable(plan, record) {
+		return nil, nil
+	}
+	return plan, nil
+}
+
+func (f *FixEngine) approvePlan(plan *FixPlan, originalCommand string) (bool, error) {
+	if !plan.needsApproval() {
+		return true, nil
 	}
 
-	fixType := suggestion.FixType
-	if fixType == "" {
-		fixType = "preparation"
+	if !onHost(f, "sandbox", func() bool { return f.Sandbox == nil || f.trialPlan(plan, originalCommand) }) {
+		return false, nil
 	}
 
-	if suggestion.RiskLevel == llm.RiskLow {
-		return suggestion.ProposedFix, fixType, nil
+	if plan.RiskLevel == llm.RiskLow {
+		return true, nil
 	}
 
 	cfg := config.Get()
 	if cfg.Safety.AutoExecute {
-		return suggestion.ProposedFix, fixType, nil
+		return true, nil
 	}
 
-	fmt.Printf("[LLM Suggestion] %s\n", suggestion.Explanation)
-	fmt.Printf("[Proposed Fix] %s\n", suggestion.ProposedFix)
-	fmt.Printf("[Risk Level] %s\n", suggestion.RiskLevel)
+	approved, err := f.confirm(&prompt.Question{
+		Kind:     prompt.KindApproveFix,
+		Text:     "Apply this fix?",
+		Commands: plan.Commands(),
+		Risk:     plan.RiskLevel,
+		Sudo:     plan.RequiresSudo(),
+	})
+	if err == nil && !approved {
+		err = stop(OutcomeDeclined, "fix declined by user")
+	}
+	return approved, err
+}
 
-	fmt.Print("Apply this fix? (y/N): ")
-	var response string
-	fmt.Scanln(&response)
+func (f *FixEngine) trialPlan(plan *FixPlan, originalCommand string) bool {
+	steps := plan.Commands()
+	if plan.Type != FixTypeReplacement {
+		steps = append(steps, originalCommand)
+	}
+	trialEnv := append(append([]string{}, f.Env...), plan.EnvVars()...)
 
-	if strings.ToLower(response) == "y" {
-		return suggestion.ProposedFix, fixType, nil
+	trial, err := f.Sandbox.Trial(context.Background(), f.Dir, trialEnv, steps...)
+	if err != nil {
+		f.emit(events.SandboxTrial{Commands: steps, Error: err.Error()})
+		return false
 	}
 
-	return "", "", nil
+	event := events.SandboxTrial{Commands: steps, Changes: trial.Changes, Success: trial.Success}
+	if !trial.Success && len(trial.Results) > 0 {
+		event.Failed = trial.Results[len(trial.Results)-1]
+	}
+	f.emit(event)
+	return trial.Success
 }
 
-func (f *FixEngine) getDeterministicFix(errorInfo *errorparser.ErrorInfo) string {
+func (f *FixEngine) getDeterministicFix(errorInfo *errorparser.ErrorInfo) *FixPlan {
 	switch errorInfo.Type {
 	case errorparser.ErrorTypeMissingCommand:
-		return f.installPackage(errorInfo.Command)
-	case errorparser.ErrorTypeMissingCompiler:
-		return f.installBuildEssential()
+		return deterministicPlan(f.asUser(f.installPackage(errorInfo.Command)), f.asUser(f.removePackage(errorInfo.Command)))
+	case errorparser.ErrorTypeMissingCompiler, errorparser.ErrorTypeMissingBuildTools:
+		return deterministicPlan(f.asUser(f.installBuildEssential()), f.asUser(f.removeBuildEssential()))
 	case errorparser.ErrorTypeMissingLibrary:
-		return f.installPackage(errorInfo.Package)
-	case errorparser.ErrorTypeMissingBuildTools:
-		return f.installBuildEssential()
+		return deterministicPlan(f.asUser(f.installPackage(errorInfo.Package)), f.asUser(f.removePackage(errorInfo.Package)))
 	default:
-		return ""
+		return nil
+	}
+}
+
+func deterministicPlan(command, rollback string) *FixPlan {
+	if command == "" {
+		return nil
+	}
+	return &FixPlan{
+		Steps:     []Step{NewStep(command, rollback, llm.RiskLow)},
+		Type:      FixTypePreparation,
+		Source:    SourceDeterministic,
+		RiskLevel: llm.RiskLow,
 	}
 }
 
@@ -176,19 +593,14 @@ func (f *FixEngine) installPackage(pkg string) string {
 	if pkg == "" {
 		return ""
 	}
+	return f.Environment.PackageManager.InstallCommand(pkg)
+}
 
-	switch f.Environment.PackageManager {
-	case env.PMApt:
-		return fmt.Sprintf("sudo apt-get install -y %s", pkg)
-	case env.PMDnf, env.PMYum:
-		return fmt.Sprintf("sudo dnf install -y %s", pkg)
-	case env.PMPacman:
-		return fmt.Sprintf("sudo pacman -S --noconfirm %s", pkg)
-	case env.PMBrew:
-		return fmt.Sprintf("brew install %s", pkg)
-	default:
+func (f *FixEngine) removePackage(pkg string) string {
+	if pkg == "" {
 		return ""
 	}
+	return f.Environment.PackageManager.RemoveCommand(pkg)
 }
 
 func (f *FixEngine) installBuildEssential() string {
@@ -206,6 +618,19 @@ func (f *FixEngine) installBuildEssential() string {
 	}
 }
 
+func (f *FixEngine) removeBuildEssential() string {
+	switch f.Environment.PackageManager {
+	case env.PMApt:
+		return "sudo apt-get remove -y build-essential"
+	case env.PMDnf:
+		return "sudo dnf groupremove -y 'Development Tools'"
+	case env.PMPacman:
+		return "sudo pacman -R --noconfirm base-devel"
+	default:
+		return ""
+	}
+}
+
 func isSudoCommand(cmd string) bool {
 	return strings.HasPrefix(cmd, "sudo ") || strings.HasPrefix(cmd, "sudo\t")
 }
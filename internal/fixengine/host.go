@@ -0,0 +1,23 @@
+package fixengine
+
+// Host stands between the engine and what it does on this machine
+// directly rather than through the executor: inspecting ports, file
+// ownership and install locations, consulting the knowledge base and
+// recipes, trialling fixes in the sandbox and saving .env files. A
+// cassette records what each call returned, so that a replay returns the
+// same without touching the machine.
+type Host interface {
+	Call(name string, out any, call func() any) error
+}
+
+// onHost runs call, through f.Host when one is set.
+func onHost[T any](f *FixEngine, name string, call func() T) T {
+	if f.Host == nil {
+		return call()
+	}
+	var out T
+	if err := f.Host.Call(name, &out, func() any { return call() }); err != nil {
+		f.notice("Host", "%s: %v", name, err)
+	}
+	return out
+}
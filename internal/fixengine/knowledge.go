@@ -0,0 +1,125 @@
+package fixengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/kb"
+)
+
+const SourceKB = "kb"
+
+func (f *FixEngine) kbEnvironment() kb.Environment {
+	return kb.Environment{
+		OS:             string(f.Environment.OS),
+		OSVersion:      f.Environment.OSVersion,
+		PackageManager: string(f.Environment.PackageManager),
+		Architecture:   string(f.Environment.Architecture),
+	}
+}
+
+func (f *FixEngine) knownFix(errorInfo *errorparser.ErrorInfo, stderr string, record *ErrorRecord) *FixPlan {
+	if f.KB == nil {
+		return nil
+	}
+
+	candidates, err := f.KB.Candidates(errorparser.Fingerprint(errorInfo, stderr), f.kbEnvironment())
+	if err != nil {
+		f.notice("Knowledge Base", "%v", err)
+		return nil
+	}
+
+	for _, candidate := range candidates {
+		var plan FixPlan
+		if err := json.Unmarshal(candidate.Entry.Plan, &plan); err != nil || len(plan.Steps) == 0 {
+			continue
+		}
+		f.localizeEnv(&plan)
+		if len(plan.Steps) == 0 || !f.usable(&plan, record) {
+			continue
+		}
+		if plan.Origin == "" {
+			plan.Origin = plan.Source
+		}
+		plan.Source = SourceKB
+		plan.Explanation = fmt.Sprintf("worked %d of %d times on matching systems", candidate.Entry.Successes, candidate.Entry.Successes+candidate.Entry.Failures)
+		return &plan
+	}
+	return nil
+}
+
+func (f *FixEngine) learn(record *ErrorRecord, plan *FixPlan, success bool) {
+	// Replacement plans run the failed command itself, which the entry's
+	// key does not hold, and port fixes target a specific PID or free
+	// port; neither carries over.
+	if f.KB == nil || plan == nil || plan.Type == FixTypeReplacement || record.Type == errorparser.ErrorTypePortInUse {
+		return
+	}
+
+	portable := *plan
+	portable.Steps = make([]Step, len(plan.Steps))
+	for i, step := range plan.Steps {
+		step.Env = portableEnv(step.Env)
+		portable.Steps[i] = step
+	}
+	data, err := json.Marshal(&portable)
+	if err != nil {
+		return
+	}
+	if err := f.KB.Record(record.Fingerprint, string(record.Type), record.Message, record.Subject, f.kbEnvironment(), planKey(&portable), data, success); err != nil {
+		f.notice("Knowledge Base", "failed to record fix: %v", err)
+	}
+}
+
+// listVars are the variables that fixes extend by one entry.
+var listVars = map[string]bool{"PATH": true, "PKG_CONFIG_PATH": true}
+
+// portableEnv keeps only the entry a fix added to a list variable; the
+// rest is this machine's value at the time.
+func portableEnv(vars []string) []string {
+	if len(vars) == 0 {
+		return vars
+	}
+	portable := make([]string, 0, len(vars))
+	for _, v := range vars {
+		if name, value, _ := strings.Cut(v, "="); listVars[name] {
+			entry, _, _ := strings.Cut(value, string(filepath.ListSeparator))
+			v = name + "=" + entry
+		}
+		portable = append(portable, v)
+	}
+	return portable
+}
+
+// localizeEnv turns the entries portableEnv kept back into additions to
+// the current values, leaving out those already there and any step left
+// with nothing to do.
+func (f *FixEngine) localizeEnv(plan *FixPlan) {
+	steps := plan.Steps[:0]
+	for _, step := range plan.Steps {
+		env := []string{}
+		for _, v := range step.Env {
+			if name, entry, _ := strings.Cut(v, "="); listVars[name] {
+				current := f.lookupEnv(name)
+				if containsPath(current, entry) {
+					continue
+				}
+				if current != "" {
+					v += string(filepath.ListSeparator) + current
+				}
+			}
+			env = append(env, v)
+		}
+		if step.Command == "" && len(env) == 0 {
+			continue
+		}
+		if len(step.Env) > 0 {
+			step.Env = env
+		}
+		steps = append(steps, step)
+	}
+	plan.Steps = steps
+}
@@ -0,0 +1,117 @@
+package fixengine
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/kb"
+	"github.com/autofix/cli/internal/llm"
+)
+
+func TestLearn(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	tests := []struct {
+		name    string
+		plan    *FixPlan
+		learned bool
+		env     []string
+	}{
+		{
+			name: "preparation with PATH",
+			plan: &FixPlan{Type: FixTypePreparation, Steps: []Step{
+				NewStep("npm install -D tsc", "", llm.RiskLow),
+				{Env: []string{"PATH=/project/node_modules/.bin" + sep + "/usr/bin" + sep + "/bin"}},
+			}},
+			learned: true,
+			env:     []string{"PATH=/project/node_modules/.bin"},
+		},
+		{
+			name: "other variables kept whole",
+			plan: &FixPlan{Type: FixTypePreparation, Steps: []Step{
+				{Env: []string{"DATABASE_URL=postgres://localhost" + sep + "5432/db"}},
+			}},
+			learned: true,
+			env:     []string{"DATABASE_URL=postgres://localhost" + sep + "5432/db"},
+		},
+		{
+			name: "replacement",
+			plan: &FixPlan{Type: FixTypeReplacement, Steps: []Step{
+				NewStep("poetry run pytest", "", llm.RiskLow),
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := kb.Open(t.TempDir())
+			f := &FixEngine{Environment: &env.Environment{OS: env.OSDebian, PackageManager: env.PMApt}, KB: store}
+			record := &ErrorRecord{Fingerprint: "abc", Type: errorparser.ErrorTypeMissingCommand}
+			f.learn(record, tt.plan, true)
+
+			candidates, err := store.Candidates("abc", f.kbEnvironment())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.learned {
+				if len(candidates) != 0 {
+					t.Fatalf("learned %d entries, want none", len(candidates))
+				}
+				return
+			}
+			if len(candidates) != 1 {
+				t.Fatalf("learned %d entries, want 1", len(candidates))
+			}
+			var stored FixPlan
+			if err := json.Unmarshal(candidates[0].Entry.Plan, &stored); err != nil {
+				t.Fatal(err)
+			}
+			if got := stored.Steps[len(stored.Steps)-1].Env; !reflect.DeepEqual(got, tt.env) {
+				t.Errorf("stored env = %q, want %q", got, tt.env)
+			}
+			if candidates[0].Entry.FixKey != planKey(&stored) {
+				t.Errorf("fix key %q is not the stored plan's %q", candidates[0].Entry.FixKey, planKey(&stored))
+			}
+		})
+	}
+}
+
+func TestLocalizeEnv(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	tests := []struct {
+		name string
+		path string
+		env  []string
+		want []Step
+	}{
+		{
+			name: "prepended",
+			path: "/usr/bin",
+			env:  []string{"PATH=/project/.venv/bin"},
+			want: []Step{{Env: []string{"PATH=/project/.venv/bin" + sep + "/usr/bin"}}},
+		},
+		{
+			name: "already there",
+			path: "/project/.venv/bin" + sep + "/usr/bin",
+			env:  []string{"PATH=/project/.venv/bin"},
+			want: []Step{},
+		},
+		{
+			name: "unset",
+			env:  []string{"PKG_CONFIG_PATH=/opt/lib/pkgconfig"},
+			want: []Step{{Env: []string{"PKG_CONFIG_PATH=/opt/lib/pkgconfig"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &FixEngine{Env: []string{"PATH=" + tt.path, "PKG_CONFIG_PATH="}}
+			plan := &FixPlan{Steps: []Step{{Env: tt.env}}}
+			f.localizeEnv(plan)
+			if !reflect.DeepEqual(plan.Steps, tt.want) {
+				t.Errorf("steps = %+v, want %+v", plan.Steps, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package fixengine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/autofix/cli/internal/executor"
+)
+
+// Outcome is how a healing session ended.
+type Outcome string
+
+const (
+	OutcomeSucceeded  Outcome = "succeeded"
+	OutcomeFixed      Outcome = "fixed"
+	OutcomeNoFix      Outcome = "no_fix"
+	OutcomeDeclined   Outcome = "declined"
+	OutcomeBlocked    Outcome = "blocked"
+	OutcomeMaxRetries Outcome = "max_retries"
+	OutcomeFixFailed  Outcome = "fix_failed"
+	OutcomeError      Outcome = "error"
+)
+
+// ExitCode is the documented process exit code for an outcome.
+func (o Outcome) ExitCode() int {
+	switch o {
+	case OutcomeSucceeded, OutcomeFixed:
+		return 0
+	case OutcomeNoFix:
+		return 3
+	case OutcomeDeclined:
+		return 4
+	case OutcomeBlocked:
+		return 5
+	case OutcomeMaxRetries:
+		return 6
+	case OutcomeFixFailed:
+		return 7
+	default:
+		return 1
+	}
+}
+
+type outcomeError struct {
+	outcome Outcome
+	err     error
+}
+
+func (e *outcomeError) Error() string { return e.err.Error() }
+func (e *outcomeError) Unwrap() error { return e.err }
+
+// stop returns an error that ends the session with the given outcome.
+func stop(outcome Outcome, format string, args ...any) error {
+	return &outcomeError{outcome: outcome, err: fmt.Errorf(format, args...)}
+}
+
+// Blocked wraps err as a safety block.
+func Blocked(err error) error {
+	return &outcomeError{outcome: OutcomeBlocked, err: err}
+}
+
+// OutcomeOf classifies the result of ExecuteWithRetry. fixed reports
+// whether any fix was applied along the way.
+func OutcomeOf(result *executor.Result, err error, fixed bool) Outcome {
+	var stopped *outcomeError
+	switch {
+	case errors.As(err, &stopped):
+		return stopped.outcome
+	case err != nil:
+		return OutcomeError
+	case result == nil || !result.Success:
+		return OutcomeNoFix
+	case fixed:
+		return OutcomeFixed
+	default:
+		return OutcomeSucceeded
+	}
+}
@@ -0,0 +1,253 @@
+package fixengine
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+)
+
+const (
+	permRead  = 04
+	permWrite = 02
+	permExec  = 01
+)
+
+type pathOwnership struct {
+	Path  string
+	Mode  os.FileMode
+	UID   int
+	GID   int
+	User  string
+	Group string
+}
+
+func (f *FixEngine) permissionPlans(errorInfo *errorparser.ErrorInfo, command string) []*FixPlan {
+	if errorInfo.Type != errorparser.ErrorTypePermissionDenied {
+		return nil
+	}
+	if !executor.IsLocal(f.Executor) {
+		return nil
+	}
+
+	denied := f.absPath(errorInfo.Path)
+	plans := []*FixPlan{}
+	if owner := inspectOwnership(denied); owner != nil {
+		f.notice("Permission Denied", "%s is owned by %s:%s with mode %s", owner.Path, owner.User, owner.Group, owner.Mode)
+		need := neededAccess(denied, owner, f.absPath(firstField(command)))
+		home, _ := os.UserHomeDir()
+
+		if home != "" && !isUnder(owner.Path, home) {
+			if plan := userInstallPlan(command, home, owner.Path); plan != nil {
+				plans = append(plans, plan)
+			}
+		}
+
+		if owner.UID == os.Getuid() {
+			plans = append(plans, chmodPlan(owner, need))
+		} else if f.Environment.HasSudo && home != "" && isUnder(owner.Path, home) {
+			plans = append(plans, chownPlan(owner, home))
+		}
+
+		// Joining the group would only take effect in a new login session,
+		// not for the retry, so it is only mentioned.
+		if owner.GID != 0 && !inGroup(owner.GID) && int(owner.Mode.Perm()>>3)&need == need {
+			f.notice("Permission Denied", "members of group %s can access %s; joining it applies to new login sessions only", owner.Group, owner.Path)
+		}
+	}
+
+	if f.Environment.HasSudo && !isSudoCommand(command) {
+		plans = append(plans, &FixPlan{
+			Steps:       []Step{NewStep("sudo "+command, "", llm.RiskHigh)},
+			Type:        FixTypeReplacement,
+			Source:      SourceDeterministic,
+			RiskLevel:   llm.RiskHigh,
+			Explanation: "rerun the command with sudo",
+		})
+	}
+	return plans
+}
+
+func inspectOwnership(path string) *pathOwnership {
+	if path == "" {
+		return nil
+	}
+
+	// The denied path may not exist yet; what blocks its creation is the
+	// nearest existing parent.
+	for {
+		info, err := os.Stat(path)
+		if err == nil {
+			stat, ok := info.Sys().(*syscall.Stat_t)
+			if !ok {
+				return nil
+			}
+			owner := &pathOwnership{Path: path, Mode: info.Mode(), UID: int(stat.Uid), GID: int(stat.Gid)}
+			owner.User, owner.Group = strconv.Itoa(owner.UID), strconv.Itoa(owner.GID)
+			if u, err := user.LookupId(owner.User); err == nil {
+				owner.User = u.Username
+			}
+			if g, err := user.LookupGroupId(owner.Group); err == nil {
+				owner.Group = g.Name
+			}
+			return owner
+		}
+		// Never fall back as far as the root directory.
+		parent := filepath.Dir(path)
+		if parent == path || parent == "/" {
+			return nil
+		}
+		path = parent
+	}
+}
+
+// absPath resolves a path from an error message against the working
+// directory.
+func (f *FixEngine) absPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(filepath.Join(f.Dir, path)); err == nil {
+		return abs
+	}
+	return path
+}
+
+func firstField(command string) string {
+	if fields := strings.Fields(command); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+func neededAccess(denied string, owner *pathOwnership, program string) int {
+	switch {
+	case owner.Path != denied:
+		return permWrite | permExec
+	case owner.Mode.IsDir():
+		return permWrite | permExec
+	case owner.Mode&os.ModeSocket != 0:
+		return permRead | permWrite
+	}
+	if program == denied {
+		return permExec
+	}
+	if file, err := os.Open(denied); err == nil {
+		file.Close()
+		return permWrite
+	}
+	return permRead
+}
+
+func userInstallPlan(command, home, target string) *FixPlan {
+	fields := strings.Fields(command)
+	if len(fields) < 2 {
+		return nil
+	}
+	local := filepath.Join(home, ".local")
+
+	var replacement string
+	switch {
+	case fields[0] == "npm" && (contains(fields, "-g") || contains(fields, "--global")):
+		replacement = command + " --prefix " + local
+	case strings.HasPrefix(fields[0], "pip") && fields[1] == "install" && !contains(fields, "--user"):
+		replacement = strings.Join(append([]string{fields[0], "install", "--user"}, fields[2:]...), " ")
+	case fields[0] == "make" && contains(fields, "install"):
+		replacement = command + " PREFIX=" + local
+	default:
+		return nil
+	}
+
+	return &FixPlan{
+		Steps:       []Step{NewStep(replacement, "", llm.RiskLow)},
+		Type:        FixTypeReplacement,
+		Source:      SourceDeterministic,
+		RiskLevel:   llm.RiskLow,
+		Explanation: fmt.Sprintf("install under %s instead of %s", local, target),
+	}
+}
+
+func chmodPlan(owner *pathOwnership, need int) *FixPlan {
+	bits := ""
+	for _, p := range []struct {
+		bit  int
+		name string
+	}{{permRead, "r"}, {permWrite, "w"}, {permExec, "x"}} {
+		if need&p.bit != 0 {
+			bits += p.name
+		}
+	}
+	path := executor.ShellQuote(owner.Path)
+	command := fmt.Sprintf("chmod u+%s %s", bits, path)
+	rollback := fmt.Sprintf("chmod %o %s", owner.Mode.Perm(), path)
+	step := NewStep(command, rollback, llm.RiskLow)
+	step.Attrs = []string{owner.Path}
+	return &FixPlan{
+		Steps:       []Step{step},
+		Type:        FixTypePreparation,
+		Source:      SourceDeterministic,
+		RiskLevel:   llm.RiskLow,
+		Explanation: fmt.Sprintf("you own %s but its mode %s does not allow this access", owner.Path, owner.Mode),
+	}
+}
+
+func chownPlan(owner *pathOwnership, home string) *FixPlan {
+	// Fix the whole top-level entry under the home directory, such as
+	// ~/.npm, since a root-run tool usually left more than one file behind.
+	// The home directory itself is only chowned, not everything below it.
+	top, recursive := owner.Path, false
+	if rel, err := filepath.Rel(home, owner.Path); err == nil && rel != "." {
+		top, recursive = filepath.Join(home, strings.Split(rel, string(filepath.Separator))[0]), true
+	}
+	flags := ""
+	if recursive {
+		flags = "-R "
+	}
+	// There is no rollback command: chown -R back to one owner would
+	// flatten mixed ownership, so undo restores each path's owner from the
+	// journal instead.
+	command := fmt.Sprintf("sudo chown %s%d:%d %s", flags, os.Getuid(), os.Getgid(), executor.ShellQuote(top))
+	step := NewStep(command, "", llm.RiskMedium)
+	step.Attrs, step.Recursive = []string{top}, recursive
+	return &FixPlan{
+		Steps:       []Step{step},
+		Type:        FixTypePreparation,
+		Source:      SourceDeterministic,
+		RiskLevel:   llm.RiskMedium,
+		Explanation: fmt.Sprintf("%s is in your home directory but owned by %s", top, owner.User),
+	}
+}
+
+func inGroup(gid int) bool {
+	if os.Getgid() == gid {
+		return true
+	}
+	groups, _ := os.Getgroups()
+	for _, g := range groups {
+		if g == gid {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnder(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package fixengine
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestChownPlan(t *testing.T) {
+	ids := fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	tests := []struct {
+		name      string
+		path      string
+		command   string
+		recursive bool
+	}{
+		{name: "top-level entry", path: "/home/dev/.npm", command: "sudo chown -R " + ids + " /home/dev/.npm", recursive: true},
+		{name: "nested path", path: "/home/dev/.npm/_cacache/index", command: "sudo chown -R " + ids + " /home/dev/.npm", recursive: true},
+		{name: "home itself", path: "/home/dev", command: "sudo chown " + ids + " /home/dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := chownPlan(&pathOwnership{Path: tt.path, User: "root"}, "/home/dev")
+			step := plan.Steps[0]
+			if step.Command != tt.command {
+				t.Errorf("command = %q, want %q", step.Command, tt.command)
+			}
+			if step.Recursive != tt.recursive {
+				t.Errorf("recursive = %v, want %v", step.Recursive, tt.recursive)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package fixengine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/events"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/prompt"
+	"github.com/autofix/cli/internal/safety"
+)
+
+type Step struct {
+	Command      string        `json:"command,omitempty"`
+	Env          []string      `json:"env,omitempty"`
+	RiskLevel    llm.RiskLevel `json:"risk_level"`
+	RequiresSudo bool          `json:"requires_sudo"`
+	Precondition string        `json:"precondition,omitempty"`
+	Rollback     string        `json:"rollback,omitempty"`
+	// Files and Attrs name what the step changes, so that the journal can
+	// back it up first: whole files, or only the mode and owner of each
+	// path, and of everything below it when Recursive is set.
+	Files     []string `json:"files,omitempty"`
+	Attrs     []string `json:"attrs,omitempty"`
+	Recursive bool     `json:"recursive,omitempty"`
+}
+
+func NewStep(command, rollback string, risk llm.RiskLevel) Step {
+	return Step{
+		Command:      command,
+		RiskLevel:    risk,
+		RequiresSudo: isSudoCommand(command),
+		Rollback:     rollback,
+	}
+}
+
+func (s Step) String() string {
+	if len(s.Env) > 0 {
+		return "export " + strings.Join(s.Env, " ")
+	}
+	return s.Command
+}
+
+type FixPlan struct {
+	Steps  []Step `json:"steps"`
+	Type   string `json:"type"`
+	Source string `json:"source"`
+	// Origin is where a knowledge base plan was first proposed.
+	Origin      string        `json:"origin,omitempty"`
+	RiskLevel   llm.RiskLevel `json:"risk_level"`
+	Explanation string        `json:"explanation,omitempty"`
+}
+
+func (p *FixPlan) Commands() []string {
+	commands := []string{}
+	for _, step := range p.Steps {
+		if step.Command != "" {
+			commands = append(commands, step.Command)
+		}
+	}
+	return commands
+}
+
+func (p *FixPlan) EnvVars() []string {
+	vars := []string{}
+	for _, step := range p.Steps {
+		vars = append(vars, step.Env...)
+	}
+	return vars
+}
+
+func (p *FixPlan) RequiresSudo() bool {
+	for _, step := range p.Steps {
+		if step.RequiresSudo {
+			return true
+		}
+	}
+	return false
+}
+
+// Event describes the plan for the event stream.
+func (p *FixPlan) Event() *events.Fix {
+	steps := make([]string, 0, len(p.Steps))
+	for _, step := range p.Steps {
+		steps = append(steps, step.String())
+	}
+	return &events.Fix{
+		Type:         p.Type,
+		Source:       p.Source,
+		RiskLevel:    string(p.RiskLevel),
+		Explanation:  p.Explanation,
+		Steps:        steps,
+		RequiresSudo: p.RequiresSudo(),
+	}
+}
+
+// needsApproval reports whether the plan came from the LLM or a recipe,
+// directly or through the knowledge base. Those are trialled and, above
+// low risk, confirmed; the engine's own rules are trusted.
+func (p *FixPlan) needsApproval() bool {
+	source := p.Source
+	if source == SourceKB {
+		source = p.Origin
+	}
+	return source != SourceDeterministic
+}
+
+func planFromSuggestion(suggestion *llm.Suggestion) *FixPlan {
+	plan := &FixPlan{
+		Type:        suggestion.FixType,
+		Source:      SourceLLM,
+		RiskLevel:   suggestion.RiskLevel,
+		Explanation: suggestion.Explanation,
+	}
+	if plan.Type == "" {
+		plan.Type = FixTypePreparation
+	}
+
+	for _, s := range suggestion.Steps {
+		if s.Command == "" {
+			continue
+		}
+		risk := s.RiskLevel
+		if risk == "" {
+			risk = suggestion.RiskLevel
+		}
+		step := NewStep(s.Command, s.Rollback, risk)
+		step.Precondition = s.Precondition
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	if len(plan.Steps) == 0 && suggestion.ProposedFix != "" {
+		plan.Steps = []Step{NewStep(suggestion.ProposedFix, "", suggestion.RiskLevel)}
+	}
+	return plan
+}
+
+func (f *FixEngine) executePlan(plan *FixPlan) (*executor.Result, error) {
+	cfg := config.Get()
+	validator := safety.NewValidator()
+	completed := []Step{}
+	var last *executor.Result
+	var failure error
+
+	for i, step := range plan.Steps {
+		if step.Precondition != "" {
+			check, err := f.run(step.Precondition)
+			if err != nil {
+				return nil, err
+			}
+			if !check.Success {
+				failure = fmt.Errorf("precondition failed for step %d: %s", i+1, step.Precondition)
+				break
+			}
+		}
+
+		if len(step.Env) > 0 {
+			for _, v := range step.Env {
+				f.emit(events.EnvironmentSet{Var: v})
+			}
+			f.Env = append(f.Env, step.Env...)
+			completed = append(completed, step)
+			continue
+		}
+
+		if err := validator.CheckDestructive(step.Command); err != nil {
+			failure = Blocked(fmt.Errorf("fix %q blocked: %w", step.Command, err))
+			break
+		}
+
+		if step.RequiresSudo && cfg.Safety.RequireSudoConfirm {
+			ok, err := f.confirm(&prompt.Question{
+				Kind:     prompt.KindSudo,
+				Text:     "This command requires sudo. Execute?",
+				Commands: []string{step.Command},
+				Risk:     step.RiskLevel,
+				Sudo:     true,
+			})
+			if err != nil {
+				failure = err
+				break
+			}
+			if !ok {
+				failure = stop(OutcomeDeclined, "sudo command declined")
+				break
+			}
+		}
+
+		if err := f.backupStep(step); err != nil {
+			failure = fmt.Errorf("failed to write journal: %w", err)
+			break
+		}
+		result, err := f.run(step.Command)
+		if err != nil {
+			return nil, err
+		}
+		f.journalCommand(step.Command, step.Rollback, result)
+		if !result.Success {
+			f.emit(events.FixFailed{Command: step.Command, Result: result})
+			failure = stop(OutcomeFixFailed, "fix command failed: %s", result.Stderr)
+			break
+		}
+		last = result
+		completed = append(completed, step)
+	}
+
+	if failure != nil {
+		f.offerRollback(completed)
+		return nil, failure
+	}
+
+	if vars := plan.EnvVars(); len(vars) > 0 {
+		if err := f.offerDotenvSave(vars); err != nil {
+			return last, err
+		}
+	}
+	return last, nil
+}
+
+func (f *FixEngine) offerRollback(completed []Step) {
+	undoable := []Step{}
+	for _, step := range completed {
+		if step.Rollback != "" || len(step.Env) > 0 {
+			undoable = append(undoable, step)
+		}
+	}
+	if len(undoable) == 0 {
+		return
+	}
+
+	commands := []string{}
+	for i := len(undoable) - 1; i >= 0; i-- {
+		if undoable[i].Rollback != "" {
+			commands = append(commands, undoable[i].Rollback)
+		} else {
+			commands = append(commands, "unset "+strings.Join(envKeys(undoable[i].Env), " "))
+		}
+	}
+	f.emit(events.RollbackOffered{Commands: commands})
+	ok, err := f.confirm(&prompt.Question{Kind: prompt.KindRollback, Text: "Roll back the completed steps?", Commands: commands})
+	if err != nil {
+		f.notice("Rollback", "%v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	for i := len(undoable) - 1; i >= 0; i-- {
+		step := undoable[i]
+		if len(step.Env) > 0 {
+			f.Env = removeEnv(f.Env, step.Env)
+			continue
+		}
+		result, err := f.run(step.Rollback)
+		if err == nil {
+			f.journalCommand(step.Rollback, "", result)
+		}
+		f.emit(events.RolledBack{Command: step.Command, Rollback: step.Rollback, Success: err == nil && result.Success})
+	}
+}
+
+func envKeys(vars []string) []string {
+	keys := make([]string, 0, len(vars))
+	for _, v := range vars {
+		key, _, _ := strings.Cut(v, "=")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func removeEnv(current, vars []string) []string {
+	remove := map[string]bool{}
+	for _, v := range vars {
+		remove[v] = true
+	}
+	kept := []string{}
+	for _, v := range current {
+		if !remove[v] {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,122 @@
+package fixengine
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/ports"
+)
+
+func (f *FixEngine) portPlans(errorInfo *errorparser.ErrorInfo, command string) []*FixPlan {
+	if errorInfo.Type != errorparser.ErrorTypePortInUse {
+		return nil
+	}
+	if !executor.IsLocal(f.Executor) {
+		return nil
+	}
+	if errorInfo.Port == "" {
+		errorInfo.Port = f.guessPort(command)
+	}
+	port, err := strconv.Atoi(errorInfo.Port)
+	if err != nil {
+		return nil
+	}
+
+	// Rerunning on a free port leaves the owner alone, so it is offered
+	// first; stopping the owner comes next if that is declined.
+	cfg := config.Get()
+	plans := []*FixPlan{}
+	if free, err := ports.Free(port); err == nil {
+		if plan := rerunOnPort(command, errorInfo.Port, free, cfg.Ports.RerunWith); plan != nil {
+			plans = append(plans, plan)
+		}
+	}
+
+	if owner, err := ports.Owner(port); err == nil && owner != nil {
+		f.notice("Port In Use", "port %d is held by %s", port, owner)
+		if cfg.Ports.StopOwner && owner.PID > 0 {
+			plans = append(plans, stopPlan(owner, port))
+		}
+	}
+	return plans
+}
+
+func (f *FixEngine) guessPort(command string) string {
+	candidates := []string{}
+	for _, field := range strings.Fields(command) {
+		if i := strings.LastIndexAny(field, ":="); i >= 0 {
+			field = field[i+1:]
+		}
+		candidates = append(candidates, field)
+	}
+	candidates = append(candidates, f.lookupEnv("PORT"))
+
+	for _, candidate := range candidates {
+		port, err := strconv.Atoi(candidate)
+		if err != nil || port <= 0 || port > 65535 {
+			continue
+		}
+		if _, err := ports.Owner(port); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func stopPlan(owner *ports.Process, port int) *FixPlan {
+	command := fmt.Sprintf("kill -TERM %d", owner.PID)
+	if uid := os.Getuid(); uid != 0 && uid != owner.UID {
+		command = "sudo " + command
+	}
+	return &FixPlan{
+		Steps:       []Step{NewStep(command, "", llm.RiskMedium)},
+		Type:        FixTypePreparation,
+		Source:      SourceDeterministic,
+		RiskLevel:   llm.RiskMedium,
+		Explanation: fmt.Sprintf("stop %s, which is listening on port %d", owner, port),
+	}
+}
+
+func rerunOnPort(command, oldPort string, port int, option string) *FixPlan {
+	newPort := strconv.Itoa(port)
+	explanation := fmt.Sprintf("rerun the command on free port %d", port)
+
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		if field == oldPort || strings.HasSuffix(field, ":"+oldPort) || strings.HasSuffix(field, "="+oldPort) {
+			fields[i] = strings.TrimSuffix(field, oldPort) + newPort
+			return replacementPlan(strings.Join(fields, " "), explanation)
+		}
+	}
+
+	switch {
+	case option == "":
+		return nil
+	case strings.HasPrefix(option, "-"):
+		return replacementPlan(command+" "+option+" "+newPort, explanation)
+	default:
+		return &FixPlan{
+			Steps:       []Step{{Env: []string{option + "=" + newPort}, RiskLevel: llm.RiskLow}},
+			Type:        FixTypeEnvironment,
+			Source:      SourceDeterministic,
+			RiskLevel:   llm.RiskLow,
+			Explanation: explanation,
+		}
+	}
+}
+
+func replacementPlan(command, explanation string) *FixPlan {
+	return &FixPlan{
+		Steps:       []Step{NewStep(command, "", llm.RiskLow)},
+		Type:        FixTypeReplacement,
+		Source:      SourceDeterministic,
+		RiskLevel:   llm.RiskLow,
+		Explanation: explanation,
+	}
+}
@@ -0,0 +1,50 @@
+package fixengine
+
+import (
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/recipes"
+)
+
+const SourceRecipe = "recipe"
+
+func (f *FixEngine) recipeFix(errorInfo *errorparser.ErrorInfo, command, stderr string, record *ErrorRecord) *FixPlan {
+	for _, r := range recipes.Find(f.Recipes, errorInfo, f.Environment, command, stderr) {
+		plan := PlanFromRecipe(r)
+		f.localizeEnv(plan)
+		if len(plan.Steps) > 0 && f.usable(plan, record) {
+			return plan
+		}
+	}
+	return nil
+}
+
+func PlanFromRecipe(r *recipes.Recipe) *FixPlan {
+	plan := &FixPlan{
+		Type:        r.Fix.Type,
+		Source:      SourceRecipe,
+		RiskLevel:   llm.RiskLevel(r.Fix.RiskLevel),
+		Explanation: r.Name,
+	}
+	if plan.Type == "" {
+		plan.Type = FixTypePreparation
+	}
+	if plan.RiskLevel == "" {
+		plan.RiskLevel = llm.RiskMedium
+	}
+	if r.Fix.Explanation != "" {
+		plan.Explanation = r.Name + ": " + r.Fix.Explanation
+	}
+
+	for _, s := range r.Fix.Steps {
+		risk := llm.RiskLevel(s.RiskLevel)
+		if risk == "" {
+			risk = plan.RiskLevel
+		}
+		step := NewStep(s.Command, s.Rollback, risk)
+		step.Env = s.Env
+		step.Precondition = s.Precondition
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan
+}
@@ -0,0 +1,27 @@
+package fixengine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		backoff time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{0, 3, 0},
+		{time.Second, 0, time.Second},
+		{time.Second, 1, 2 * time.Second},
+		{time.Second, 3, 8 * time.Second},
+		{time.Minute, 10, maxBackoff},
+		{time.Minute, 1000, maxBackoff},
+		{time.Hour, 2, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.backoff, tt.attempt); got != tt.want {
+			t.Errorf("backoffDelay(%s, %d) = %s, want %s", tt.backoff, tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package fixengine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/autofix/cli/internal/errorparser"
+	"github.com/autofix/cli/internal/events"
+)
+
+type ErrorStatus string
+
+const (
+	StatusUnresolved ErrorStatus = "unresolved"
+	StatusResolved   ErrorStatus = "resolved"
+	StatusRegressed  ErrorStatus = "regressed"
+)
+
+type ErrorRecord struct {
+	Fingerprint string                `json:"fingerprint"`
+	Type        errorparser.ErrorType `json:"type"`
+	Message     string                `json:"message"`
+	// Subject is the command, package or path the error names.
+	Subject     string      `json:"subject,omitempty"`
+	Attempt     int         `json:"attempt"`
+	Status      ErrorStatus `json:"status"`
+	ResolvedBy  string      `json:"resolved_by,omitempty"`
+	RegressedBy string      `json:"regressed_by,omitempty"`
+	Tried       []string    `json:"tried,omitempty"`
+}
+
+type Session struct {
+	Errors    []*ErrorRecord                                         `json:"errors"`
+	Outcome   Outcome                                                `json:"outcome,omitempty"`
+	OnOutcome func(record *ErrorRecord, plan *FixPlan, success bool) `json:"-"`
+
+	byFingerprint map[string]*ErrorRecord
+	pending       *pendingFix
+	resolved      []*pendingFix
+	events        *events.Bus
+}
+
+type pendingFix struct {
+	fingerprint string
+	fix         string
+	plan        *FixPlan
+}
+
+func subject(info *errorparser.ErrorInfo) string {
+	for _, s := range []string{info.Command, info.Package, info.Path} {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func NewSession() *Session {
+	return &Session{byFingerprint: map[string]*ErrorRecord{}}
+}
+
+func (s *Session) observe(fingerprint string, info *errorparser.ErrorInfo, attempt int) (*ErrorRecord, error) {
+	record, seen := s.byFingerprint[fingerprint]
+	if !seen {
+		record = &ErrorRecord{
+			Fingerprint: fingerprint,
+			Type:        info.Type,
+			Message:     info.Message,
+			Subject:     subject(info),
+			Attempt:     attempt,
+			Status:      StatusUnresolved,
+		}
+		s.byFingerprint[fingerprint] = record
+		s.Errors = append(s.Errors, record)
+	}
+
+	pending := s.pending
+	s.pending = nil
+	if pending == nil {
+		return record, nil
+	}
+
+	if pending.fingerprint == fingerprint {
+		s.outcome(record, pending.plan, false)
+		s.events.Emit(events.Notice{Tag: "No Effect", Text: fmt.Sprintf("%q did not change the %s error", pending.fix, record.Type)})
+		return record, nil
+	}
+
+	s.resolve(pending)
+
+	if record.Status == StatusResolved {
+		record.Status = StatusRegressed
+		record.RegressedBy = pending.fix
+		return record, fmt.Errorf("fix %q brought back the %s error previously resolved by %q", pending.fix, record.Type, record.ResolvedBy)
+	}
+	return record, nil
+}
+
+// succeeded reports every fix that resolved an error as working. A fix
+// that only turned one error into another is not counted until the
+// command actually succeeds.
+func (s *Session) succeeded() {
+	if s.pending != nil {
+		s.resolve(s.pending)
+		s.pending = nil
+	}
+	for _, pending := range s.resolved {
+		s.outcome(s.byFingerprint[pending.fingerprint], pending.plan, true)
+	}
+	s.resolved = nil
+}
+
+func (s *Session) resolve(pending *pendingFix) {
+	if record := s.byFingerprint[pending.fingerprint]; record != nil {
+		record.Status = StatusResolved
+		record.ResolvedBy = pending.fix
+		s.resolved = append(s.resolved, pending)
+	}
+}
+
+func (s *Session) outcome(record *ErrorRecord, plan *FixPlan, success bool) {
+	if s.OnOutcome != nil {
+		s.OnOutcome(record, plan, success)
+	}
+}
+
+func (r *ErrorRecord) alreadyTried(plan *FixPlan) bool {
+	if r == nil {
+		return false
+	}
+	key := planKey(plan)
+	for _, tried := range r.Tried {
+		if tried == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Session) applying(record *ErrorRecord, plan *FixPlan) {
+	key := planKey(plan)
+	record.Tried = append(record.Tried, key)
+	s.pending = &pendingFix{fingerprint: record.Fingerprint, fix: key, plan: plan}
+}
+
+func planKey(plan *FixPlan) string {
+	steps := make([]string, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		steps = append(steps, step.String())
+	}
+	return strings.Join(steps, " && ")
+}
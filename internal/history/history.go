@@ -0,0 +1,195 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/autofix/cli/internal/events"
+)
+
+// Run is the saved record of one `autofix run`.
+type Run struct {
+	ID        string        `json:"id"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Dir       string        `json:"cwd"`
+	events.Report
+	Timeline []Entry `json:"timeline"`
+}
+
+// Entry is one event on a run's timeline.
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Text  string    `json:"text,omitempty"`
+}
+
+type Store struct {
+	Dir     string
+	MaxAge  time.Duration
+	MaxSize int64
+}
+
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".autofix", "history"), nil
+}
+
+var runIDPattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+func Open(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Save writes the run with secrets redacted, then rotates the store.
+func (s *Store) Save(run *Run) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if data, err = redactJSON(data); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path(run.ID), data, 0600); err != nil {
+		return err
+	}
+	return s.rotate()
+}
+
+func (s *Store) Load(id string) (*Run, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Find loads the run whose ID starts with prefix. Run IDs are made of
+// digits, letters and dashes, so a prefix with anything else, such as a
+// path separator or a glob pattern, cannot match one.
+func (s *Store) Find(prefix string) (*Run, error) {
+	if !runIDPattern.MatchString(prefix) {
+		return nil, fmt.Errorf("invalid run ID %q", prefix)
+	}
+	files, err := filepath.Glob(filepath.Join(s.Dir, prefix+"*.json"))
+	if err != nil {
+		return nil, err
+	}
+	switch len(files) {
+	case 0:
+		return nil, fmt.Errorf("no run matching %s", prefix)
+	case 1:
+		return s.Load(strings.TrimSuffix(filepath.Base(files[0]), ".json"))
+	default:
+		return nil, fmt.Errorf("%s matches %d runs", prefix, len(files))
+	}
+}
+
+type Filter struct {
+	Status  string
+	Command string
+	Since   time.Time
+	Until   time.Time
+}
+
+// Match reports whether a run passes the filter. Status is an outcome
+// name, or "failed" for any run that did not exit 0.
+func (f Filter) Match(run *Run) bool {
+	switch {
+	case f.Status == "failed" && run.ExitCode == 0:
+		return false
+	case f.Status != "" && f.Status != "failed" && run.Outcome != f.Status:
+		return false
+	case f.Command != "" && !strings.Contains(run.Command, f.Command):
+		return false
+	case !f.Since.IsZero() && run.StartedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && run.StartedAt.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// List returns the runs passing the filter, newest first.
+func (s *Store) List(filter Filter) ([]*Run, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []*Run{}
+	for _, file := range files {
+		run, err := s.Load(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil || !filter.Match(run) {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(a, b int) bool {
+		return runs[a].StartedAt.After(runs[b].StartedAt)
+	})
+	return runs, nil
+}
+
+// rotate removes runs older than MaxAge, then the oldest runs until the
+// store fits in MaxSize.
+func (s *Store) rotate() error {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	files := []file{}
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(s.Dir, entry.Name())
+		if s.MaxAge > 0 && time.Since(info.ModTime()) > s.MaxAge {
+			os.Remove(path)
+			continue
+		}
+		files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(a, b int) bool {
+		return files[a].modTime.Before(files[b].modTime)
+	})
+	for i := 0; s.MaxSize > 0 && total > s.MaxSize && i < len(files)-1; i++ {
+		if err := os.Remove(files[i].path); err == nil {
+			total -= files[i].size
+		}
+	}
+	return nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.Dir, id+".json")
+}
@@ -0,0 +1,71 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/autofix/cli/internal/events"
+)
+
+// Recorder is an event sink that builds the Run for a session.
+type Recorder struct {
+	run     *Run
+	report  *events.Report
+	console *events.Console
+	buf     bytes.Buffer
+	Now     func() time.Time
+}
+
+func NewRecorder(id, dir string) *Recorder {
+	r := &Recorder{report: events.NewReport(), Now: time.Now}
+	r.run = &Run{ID: id, StartedAt: time.Now().UTC(), Dir: dir, Timeline: []Entry{}}
+	r.console = events.NewConsole(&r.buf)
+	return r
+}
+
+func (r *Recorder) Handle(e events.Event) {
+	r.report.Handle(e)
+	text := describe(e)
+	if text == "" {
+		r.buf.Reset()
+		r.console.Handle(e)
+		text = strings.TrimSpace(r.buf.String())
+	}
+	r.run.Timeline = append(r.run.Timeline, Entry{Time: r.Now().UTC(), Event: e.Name(), Text: text})
+}
+
+// Run returns the finished record.
+func (r *Recorder) Run() *Run {
+	r.run.Report = *r.report
+	r.run.Duration = r.Now().Sub(r.run.StartedAt)
+	return r.run
+}
+
+// describe summarises the events whose console rendering is missing or
+// too verbose for a timeline.
+func describe(e events.Event) string {
+	switch e := e.(type) {
+	case events.EnvironmentDetected:
+		return fmt.Sprintf("%s %s, %s, %s", e.Environment.OS, e.Environment.OSVersion, e.Environment.Architecture, e.Environment.PackageManager)
+	case events.AttemptStarted:
+		return fmt.Sprintf("attempt %d: %s", e.Attempt+1, e.Command)
+	case events.CommandFinished:
+		return fmt.Sprintf("%s exited %d", e.Result.Command, e.Result.ExitCode)
+	case events.ErrorClassified:
+		return fmt.Sprintf("%s: %s (%s)", e.Error.Type, e.Error.Message, e.Fingerprint)
+	case events.FixProposed:
+		return fmt.Sprintf("%s %s fix, %s risk: %s", e.Fix.Source, e.Fix.Type, e.Fix.RiskLevel, strings.Join(e.Fix.Steps, " && "))
+	case events.FixConfirmed:
+		return strings.Join(e.Fix.Steps, " && ")
+	case events.FixApplied:
+		return strings.Join(e.Fix.Steps, " && ")
+	case events.SessionFinished:
+		if e.Error != "" {
+			return fmt.Sprintf("%s (exit %d): %s", e.Outcome, e.ExitCode, e.Error)
+		}
+		return fmt.Sprintf("%s (exit %d)", e.Outcome, e.ExitCode)
+	}
+	return ""
+}
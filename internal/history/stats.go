@@ -0,0 +1,80 @@
+package history
+
+import (
+	"sort"
+	"strings"
+)
+
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+type FixCount struct {
+	Fix     string `json:"fix"`
+	Applied int    `json:"applied"`
+	Fixed   int    `json:"fixed"`
+}
+
+type Stats struct {
+	Runs       int        `json:"runs"`
+	Outcomes   []Count    `json:"outcomes"`
+	ErrorTypes []Count    `json:"error_types"`
+	Fixes      []FixCount `json:"fixes"`
+}
+
+// Summarize counts outcomes, error types and applied fixes across runs.
+// A fix counts as fixed when the run it was applied in ended fixed.
+func Summarize(runs []*Run) *Stats {
+	outcomes := map[string]int{}
+	errorTypes := map[string]int{}
+	fixes := map[string]*FixCount{}
+
+	for _, run := range runs {
+		outcomes[run.Outcome]++
+		for _, attempt := range run.Attempts {
+			if attempt.Error != nil {
+				errorTypes[string(attempt.Error.Type)]++
+			}
+			if attempt.Applied == nil {
+				continue
+			}
+			key := strings.Join(attempt.Applied.Steps, " && ")
+			fix := fixes[key]
+			if fix == nil {
+				fix = &FixCount{Fix: key}
+				fixes[key] = fix
+			}
+			fix.Applied++
+			if run.Outcome == "fixed" {
+				fix.Fixed++
+			}
+		}
+	}
+
+	stats := &Stats{Runs: len(runs), Outcomes: sorted(outcomes), ErrorTypes: sorted(errorTypes), Fixes: []FixCount{}}
+	for _, fix := range fixes {
+		stats.Fixes = append(stats.Fixes, *fix)
+	}
+	sort.Slice(stats.Fixes, func(a, b int) bool {
+		if stats.Fixes[a].Applied != stats.Fixes[b].Applied {
+			return stats.Fixes[a].Applied > stats.Fixes[b].Applied
+		}
+		return stats.Fixes[a].Fix < stats.Fixes[b].Fix
+	})
+	return stats
+}
+
+func sorted(counts map[string]int) []Count {
+	list := make([]Count, 0, len(counts))
+	for key, count := range counts {
+		list = append(list, Count{Key: key, Count: count})
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Count != list[b].Count {
+			return list[a].Count > list[b].Count
+		}
+		return list[a].Key < list[b].Key
+	})
+	return list
+}
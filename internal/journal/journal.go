@@ -0,0 +1,285 @@
+package journal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/autofix/cli/internal/env"
+)
+
+const maxOutput = 16 * 1024
+
+var runIDPattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+type FileBackup struct {
+	Path    string      `json:"path"`
+	Existed bool        `json:"existed"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	Content []byte      `json:"content,omitempty"`
+	// Owner is "uid:gid". Attrs marks a backup of the mode and owner
+	// only, as taken for directories and before a chmod or chown.
+	Owner string `json:"owner,omitempty"`
+	Attrs bool   `json:"attrs,omitempty"`
+}
+
+type EnvChange struct {
+	File     string `json:"file"`
+	Key      string `json:"key"`
+	Existed  bool   `json:"existed"`
+	Previous string `json:"previous,omitempty"`
+}
+
+type Entry struct {
+	Time           time.Time          `json:"time"`
+	Command        string             `json:"command,omitempty"`
+	Rollback       string             `json:"rollback,omitempty"`
+	ExitCode       int                `json:"exit_code"`
+	Output         string             `json:"output,omitempty"`
+	PackageManager env.PackageManager `json:"package_manager,omitempty"`
+	Packages       []string           `json:"packages,omitempty"`
+	Files          []FileBackup       `json:"files,omitempty"`
+	Env            []EnvChange        `json:"env,omitempty"`
+}
+
+type Journal struct {
+	RunID     string     `json:"run_id"`
+	StartedAt time.Time  `json:"started_at"`
+	Command   string     `json:"command"`
+	Dir       string     `json:"dir,omitempty"`
+	Host      string     `json:"host,omitempty"`
+	Entries   []*Entry   `json:"entries"`
+	UndoneAt  *time.Time `json:"undone_at,omitempty"`
+
+	mu   sync.Mutex
+	path string
+}
+
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".autofix", "journal"), nil
+}
+
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+func New(runID, command, dir, host string) (*Journal, error) {
+	journalDir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{
+		RunID:     runID,
+		StartedAt: time.Now().UTC(),
+		Command:   command,
+		Dir:       dir,
+		Host:      host,
+		path:      filepath.Join(journalDir, runID+".json"),
+	}, nil
+}
+
+// Load reads the journal of runID, which must be a run ID and not a path
+// or pattern.
+func Load(runID string) (*Journal, error) {
+	if !runIDPattern.MatchString(runID) {
+		return nil, fmt.Errorf("invalid run ID %q", runID)
+	}
+	journalDir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(journalDir, runID+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no journal for run %s", runID)
+		}
+		return nil, err
+	}
+
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	j.path = path
+	return &j, nil
+}
+
+func List() ([]*Journal, error) {
+	journalDir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(journalDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	journals := []*Journal{}
+	for _, file := range files {
+		j, err := Load(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		journals = append(journals, j)
+	}
+
+	sort.Slice(journals, func(a, b int) bool {
+		return journals[a].StartedAt.After(journals[b].StartedAt)
+	})
+	return journals, nil
+}
+
+func Latest() (*Journal, error) {
+	journals, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range journals {
+		if j.UndoneAt == nil {
+			return j, nil
+		}
+	}
+	return nil, fmt.Errorf("no runs to undo")
+}
+
+func (j *Journal) RecordCommand(command, rollback string, exitCode int, output string, pm env.PackageManager) error {
+	if len(output) > maxOutput {
+		output = output[len(output)-maxOutput:]
+	}
+	entry := &Entry{
+		Time:     time.Now().UTC(),
+		Command:  command,
+		Rollback: rollback,
+		ExitCode: exitCode,
+		Output:   output,
+	}
+	if pkgs := pm.InstalledPackages(output); len(pkgs) > 0 {
+		entry.PackageManager = pm
+		entry.Packages = pkgs
+	}
+	return j.add(entry)
+}
+
+// BackupFile saves path, or records that it did not exist, before a fix
+// writes it.
+func (j *Journal) BackupFile(path string) error {
+	backup, err := backupAttrs(path)
+	if err != nil {
+		return err
+	}
+	if backup.Existed && !backup.Attrs {
+		if backup.Content, err = os.ReadFile(path); err != nil {
+			return err
+		}
+	}
+	return j.add(&Entry{Time: time.Now().UTC(), Files: []FileBackup{*backup}})
+}
+
+// BackupAttrs saves the mode and owner of each path before a fix changes
+// them, and of everything below each path when recursive is set.
+func (j *Journal) BackupAttrs(recursive bool, paths ...string) error {
+	backups := []FileBackup{}
+	add := func(path string) error {
+		backup, err := backupAttrs(path)
+		if err == nil && backup.Existed {
+			backup.Attrs = true
+			backups = append(backups, *backup)
+		}
+		return err
+	}
+	for _, path := range paths {
+		if !recursive {
+			if err := add(path); err != nil {
+				return err
+			}
+			continue
+		}
+		err := filepath.WalkDir(path, func(path string, _ fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			return add(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(backups) == 0 {
+		return nil
+	}
+	return j.add(&Entry{Time: time.Now().UTC(), Files: backups})
+}
+
+func backupAttrs(path string) (*FileBackup, error) {
+	backup := &FileBackup{Path: path}
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return backup, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backup.Existed = true
+	backup.Mode = info.Mode().Perm()
+	backup.Attrs = !info.Mode().IsRegular()
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		backup.Owner = fmt.Sprintf("%d:%d", stat.Uid, stat.Gid)
+	}
+	return backup, nil
+}
+
+func (j *Journal) RecordEnv(file, key, previous string, existed bool) error {
+	change := EnvChange{File: file, Key: key, Existed: existed, Previous: previous}
+	return j.add(&Entry{Time: time.Now().UTC(), Env: []EnvChange{change}})
+}
+
+func (j *Journal) MarkUndone() error {
+	j.mu.Lock()
+	now := time.Now().UTC()
+	j.UndoneAt = &now
+	j.mu.Unlock()
+	return j.Save()
+}
+
+func (j *Journal) add(entry *Entry) error {
+	j.mu.Lock()
+	j.Entries = append(j.Entries, entry)
+	j.mu.Unlock()
+	return j.Save()
+}
+
+func (j *Journal) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(j.path, data, 0600)
+}
@@ -0,0 +1,110 @@
+package journal_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/autofix/cli/internal/dotenv"
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/journal"
+)
+
+func TestLoadRejectsPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, runID := range []string{"", "../config", "a/b", "*", "20261001-*", "run.json"} {
+		if _, err := journal.Load(runID); err == nil || !strings.Contains(err.Error(), "invalid run ID") {
+			t.Errorf("Load(%q) = %v, want an invalid run ID error", runID, err)
+		}
+	}
+}
+
+func TestUndoPlan(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	created := filepath.Join(dir, "created")
+	edited := filepath.Join(dir, "edited")
+	script := filepath.Join(dir, "script.sh")
+	envFile := filepath.Join(dir, ".env")
+	for path, content := range map[string]string{edited: "before", script: "#!/bin/sh\n", envFile: "KEEP=old\n"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runID := journal.NewRunID()
+	j, err := journal.New(runID, "make", dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []func() error{
+		func() error {
+			return j.RecordCommand("sudo apt-get install -y sl", "", 0, "The following NEW packages will be installed:\n  sl\n", env.PMApt)
+		},
+		func() error { return j.RecordCommand("npm install -D tsc", "npm uninstall -D tsc", 0, "", env.PMApt) },
+		func() error { return j.RecordCommand("pip install x", "pip uninstall -y x", 1, "", env.PMApt) },
+		func() error { return j.BackupFile(created) },
+		func() error { return j.BackupFile(edited) },
+		func() error { return j.BackupAttrs(false, script) },
+		func() error { return j.RecordEnv(envFile, "KEEP", "old", true) },
+		func() error { return j.RecordEnv(envFile, "ADDED", "", false) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The fixes themselves.
+	os.WriteFile(created, []byte("new"), 0644)
+	os.WriteFile(edited, []byte("after"), 0644)
+	os.Chmod(script, 0755)
+	dotenv.Set(envFile, "KEEP", "new")
+	dotenv.Set(envFile, "ADDED", "1")
+
+	loaded, err := journal.Load(runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := loaded.UndoPlan()
+	kinds := []journal.ActionKind{}
+	for _, a := range actions {
+		kinds = append(kinds, a.Kind)
+	}
+	want := []journal.ActionKind{
+		journal.ActionUnsetEnv,
+		journal.ActionRestoreEnv,
+		journal.ActionRestoreMode,
+		journal.ActionRestoreFile,
+		journal.ActionDeleteFile,
+		journal.ActionRunRollback,
+		journal.ActionRemovePackages,
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("undo plan = %v, want %v", kinds, want)
+	}
+	if got := actions[len(actions)-1].Command; got != env.PMApt.RemoveCommand("sl") {
+		t.Errorf("remove command = %q", got)
+	}
+
+	for _, a := range actions {
+		if a.Command == "" {
+			if err := a.ApplyLocal(); err != nil {
+				t.Fatalf("%s: %v", a.Description, err)
+			}
+		}
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("%s still exists", created)
+	}
+	if content, _ := os.ReadFile(edited); string(content) != "before" {
+		t.Errorf("%s = %q, want it restored", edited, content)
+	}
+	if info, _ := os.Stat(script); info.Mode().Perm() != 0644 {
+		t.Errorf("%s mode = %o, want 644", script, info.Mode().Perm())
+	}
+	if vars, _ := dotenv.Load(envFile); !reflect.DeepEqual(vars, []string{"KEEP=old"}) {
+		t.Errorf(".env = %q, want it restored", vars)
+	}
+}
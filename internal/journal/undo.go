@@ -0,0 +1,176 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/autofix/cli/internal/dotenv"
+	"github.com/autofix/cli/internal/executor"
+)
+
+// maxChownPaths keeps each restore command well inside the argument limit.
+const maxChownPaths = 100
+
+type ActionKind string
+
+const (
+	ActionRemovePackages ActionKind = "remove_packages"
+	ActionRestoreFile    ActionKind = "restore_file"
+	ActionDeleteFile     ActionKind = "delete_file"
+	ActionRestoreMode    ActionKind = "restore_mode"
+	ActionRestoreOwner   ActionKind = "restore_owner"
+	ActionRestoreEnv     ActionKind = "restore_env"
+	ActionUnsetEnv       ActionKind = "unset_env"
+	ActionRunRollback    ActionKind = "run_rollback"
+)
+
+type Action struct {
+	Kind        ActionKind
+	Description string
+	Command     string
+
+	file *FileBackup
+	env  *EnvChange
+}
+
+func (j *Journal) UndoPlan() []Action {
+	actions := []Action{}
+
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		entry := j.Entries[i]
+
+		if len(entry.Packages) > 0 {
+			if command := entry.PackageManager.RemoveCommand(entry.Packages...); command != "" {
+				actions = append(actions, Action{
+					Kind:        ActionRemovePackages,
+					Description: fmt.Sprintf("uninstall %v (installed by %q)", entry.Packages, entry.Command),
+					Command:     command,
+				})
+			}
+		} else if entry.Rollback != "" && entry.ExitCode == 0 {
+			actions = append(actions, Action{
+				Kind:        ActionRunRollback,
+				Description: fmt.Sprintf("roll back %q", entry.Command),
+				Command:     entry.Rollback,
+			})
+		}
+
+		owners := map[string][]string{}
+		for k := range entry.Files {
+			backup := &entry.Files[k]
+			switch {
+			case !backup.Existed:
+				actions = append(actions, Action{Kind: ActionDeleteFile, Description: "delete " + backup.Path, file: backup})
+				continue
+			case !backup.Attrs:
+				actions = append(actions, Action{Kind: ActionRestoreFile, Description: "restore " + backup.Path, file: backup})
+			default:
+				info, err := os.Lstat(backup.Path)
+				if err == nil && info.Mode()&os.ModeSymlink == 0 && info.Mode().Perm() != backup.Mode {
+					actions = append(actions, Action{
+						Kind:        ActionRestoreMode,
+						Description: fmt.Sprintf("restore mode %o of %s", backup.Mode, backup.Path),
+						file:        backup,
+					})
+				}
+			}
+			if backup.Owner != "" && currentOwner(backup.Path) != backup.Owner {
+				owners[backup.Owner] = append(owners[backup.Owner], backup.Path)
+			}
+		}
+		actions = append(actions, restoreOwners(owners)...)
+
+		for k := range entry.Env {
+			change := &entry.Env[k]
+			if change.Existed {
+				actions = append(actions, Action{
+					Kind:        ActionRestoreEnv,
+					Description: fmt.Sprintf("restore %s in %s", change.Key, change.File),
+					env:         change,
+				})
+			} else {
+				actions = append(actions, Action{
+					Kind:        ActionUnsetEnv,
+					Description: fmt.Sprintf("remove %s from %s", change.Key, change.File),
+					env:         change,
+				})
+			}
+		}
+	}
+
+	return actions
+}
+
+// restoreOwners changes owners back with chown, which usually takes sudo
+// because the fix had taken the paths over from another user.
+func restoreOwners(owners map[string][]string) []Action {
+	keys := make([]string, 0, len(owners))
+	for owner := range owners {
+		keys = append(keys, owner)
+	}
+	sort.Strings(keys)
+
+	chown := "chown -h"
+	if os.Geteuid() != 0 {
+		chown = "sudo " + chown
+	}
+	actions := []Action{}
+	for _, owner := range keys {
+		paths := owners[owner]
+		for len(paths) > 0 {
+			batch := paths[:min(len(paths), maxChownPaths)]
+			paths = paths[len(batch):]
+			quoted := make([]string, len(batch))
+			for i, path := range batch {
+				quoted[i] = executor.ShellQuote(path)
+			}
+			description := fmt.Sprintf("restore owner %s of %s", owner, batch[0])
+			if len(batch) > 1 {
+				description = fmt.Sprintf("restore owner %s of %s and %d more", owner, batch[0], len(batch)-1)
+			}
+			actions = append(actions, Action{
+				Kind:        ActionRestoreOwner,
+				Description: description,
+				Command:     chown + " " + owner + " " + strings.Join(quoted, " "),
+			})
+		}
+	}
+	return actions
+}
+
+func currentOwner(path string) string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return ""
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("%d:%d", stat.Uid, stat.Gid)
+	}
+	return ""
+}
+
+func (a *Action) ApplyLocal() error {
+	switch a.Kind {
+	case ActionRestoreFile:
+		if err := os.WriteFile(a.file.Path, a.file.Content, a.file.Mode); err != nil {
+			return err
+		}
+		return os.Chmod(a.file.Path, a.file.Mode)
+	case ActionRestoreMode:
+		return os.Chmod(a.file.Path, a.file.Mode)
+	case ActionDeleteFile:
+		if err := os.Remove(a.file.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	case ActionRestoreEnv:
+		return dotenv.Set(a.env.File, a.env.Key, a.env.Previous)
+	case ActionUnsetEnv:
+		return dotenv.Unset(a.env.File, a.env.Key)
+	default:
+		return fmt.Errorf("%s must be run as a command", a.Kind)
+	}
+}
@@ -0,0 +1,242 @@
+package kb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Environment struct {
+	OS             string `json:"os"`
+	OSVersion      string `json:"os_version"`
+	PackageManager string `json:"package_manager"`
+	Architecture   string `json:"architecture"`
+}
+
+type Entry struct {
+	ID          string          `json:"id"`
+	Fingerprint string          `json:"fingerprint"`
+	ErrorType   string          `json:"error_type"`
+	Message     string          `json:"message"`
+	Subject     string          `json:"subject,omitempty"`
+	Environment Environment     `json:"environment"`
+	FixKey      string          `json:"fix_key"`
+	Plan        json.RawMessage `json:"plan"`
+	Successes   int             `json:"successes"`
+	Failures    int             `json:"failures"`
+	FirstSeen   time.Time       `json:"first_seen"`
+	LastUsed    time.Time       `json:"last_used"`
+}
+
+func (e *Entry) SuccessRate() float64 {
+	return float64(e.Successes+1) / float64(e.Successes+e.Failures+2)
+}
+
+type Candidate struct {
+	Entry *Entry
+	Score float64
+}
+
+type Store struct {
+	Dir string
+}
+
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".autofix", "kb"), nil
+}
+
+func Open(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+func (s *Store) Record(fingerprint, errorType, message, subject string, environment Environment, fixKey string, plan json.RawMessage, success bool) error {
+	entries, err := s.load(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	id := entryID(fingerprint, environment, fixKey)
+	var entry *Entry
+	for _, e := range entries {
+		if e.ID == id {
+			entry = e
+			break
+		}
+	}
+	if entry == nil {
+		entry = &Entry{
+			ID:          id,
+			Fingerprint: fingerprint,
+			ErrorType:   errorType,
+			Message:     message,
+			Subject:     subject,
+			Environment: environment,
+			FixKey:      fixKey,
+			Plan:        plan,
+			FirstSeen:   time.Now().UTC(),
+		}
+		entries = append(entries, entry)
+	}
+
+	if success {
+		entry.Successes++
+	} else {
+		entry.Failures++
+	}
+	entry.LastUsed = time.Now().UTC()
+
+	return s.save(fingerprint, entries)
+}
+
+func (s *Store) Candidates(fingerprint string, environment Environment) ([]Candidate, error) {
+	entries, err := s.load(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []Candidate{}
+	for _, e := range entries {
+		match := matchScore(e.Environment, environment)
+		if match == 0 || e.SuccessRate() < 0.5 {
+			continue
+		}
+		candidates = append(candidates, Candidate{Entry: e, Score: match * e.SuccessRate()})
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].Score > candidates[b].Score
+	})
+	return candidates, nil
+}
+
+func (s *Store) All() ([]*Entry, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	all := []*Entry{}
+	for _, file := range files {
+		entries, err := s.load(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+	}
+
+	sort.Slice(all, func(a, b int) bool {
+		return all[a].LastUsed.After(all[b].LastUsed)
+	})
+	return all, nil
+}
+
+func (s *Store) Find(id string) (*Entry, error) {
+	all, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range all {
+		if e.ID == id || strings.HasPrefix(e.ID, id) {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("no knowledge base entry %s", id)
+}
+
+func (s *Store) Forget(id string) (int, error) {
+	all, err := s.All()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	byFingerprint := map[string][]*Entry{}
+	touched := map[string]bool{}
+	for _, e := range all {
+		if e.ID == id || e.Fingerprint == id {
+			touched[e.Fingerprint] = true
+			removed++
+			continue
+		}
+		byFingerprint[e.Fingerprint] = append(byFingerprint[e.Fingerprint], e)
+	}
+
+	for fingerprint := range touched {
+		if err := s.save(fingerprint, byFingerprint[fingerprint]); err != nil {
+			return removed, err
+		}
+	}
+	if removed == 0 {
+		return 0, fmt.Errorf("no knowledge base entry %s", id)
+	}
+	return removed, nil
+}
+
+func (s *Store) load(fingerprint string) ([]*Entry, error) {
+	data, err := os.ReadFile(s.path(fingerprint))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *Store) save(fingerprint string, entries []*Entry) error {
+	if len(entries) == 0 {
+		err := os.Remove(s.path(fingerprint))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(fingerprint), data, 0600)
+}
+
+func (s *Store) path(fingerprint string) string {
+	return filepath.Join(s.Dir, fingerprint+".json")
+}
+
+func matchScore(recorded, current Environment) float64 {
+	if recorded.OS != current.OS || recorded.PackageManager != current.PackageManager {
+		return 0
+	}
+	score := 0.6
+	if recorded.OSVersion == current.OSVersion {
+		score += 0.3
+	}
+	if recorded.Architecture == current.Architecture {
+		score += 0.1
+	}
+	return score
+}
+
+func entryID(fingerprint string, environment Environment, fixKey string) string {
+	key := strings.Join([]string{fingerprint, environment.OS, environment.OSVersion, environment.PackageManager, environment.Architecture, fixKey}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,110 @@
+package kb_test
+
+import (
+	"testing"
+
+	"github.com/autofix/cli/internal/kb"
+)
+
+var debian12 = kb.Environment{OS: "debian", OSVersion: "12", PackageManager: "apt", Architecture: "amd64"}
+
+func TestRecordKeys(t *testing.T) {
+	type record struct {
+		environment kb.Environment
+		fixKey      string
+		success     bool
+	}
+	tests := []struct {
+		name    string
+		records []record
+		entries int
+		counts  [2]int // successes and failures of the first entry
+	}{
+		{
+			name:    "same fix counted together",
+			records: []record{{debian12, "apt install sl", true}, {debian12, "apt install sl", false}, {debian12, "apt install sl", true}},
+			entries: 1,
+			counts:  [2]int{2, 1},
+		},
+		{
+			name:    "different fixes kept apart",
+			records: []record{{debian12, "apt install sl", true}, {debian12, "snap install sl", true}},
+			entries: 2,
+			counts:  [2]int{1, 0},
+		},
+		{
+			name: "different environments kept apart",
+			records: []record{
+				{debian12, "apt install sl", true},
+				{kb.Environment{OS: "debian", OSVersion: "11", PackageManager: "apt", Architecture: "amd64"}, "apt install sl", true},
+			},
+			entries: 2,
+			counts:  [2]int{1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := kb.Open(t.TempDir())
+			for _, r := range tt.records {
+				if err := store.Record("fp", "missing_command", "Command not found", "sl", r.environment, r.fixKey, []byte(`{}`), r.success); err != nil {
+					t.Fatal(err)
+				}
+			}
+			all, err := store.All()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != tt.entries {
+				t.Fatalf("%d entries, want %d", len(all), tt.entries)
+			}
+			for _, e := range all {
+				if e.FixKey == tt.records[0].fixKey && e.Environment == tt.records[0].environment {
+					if got := [2]int{e.Successes, e.Failures}; got != tt.counts {
+						t.Errorf("counts = %v, want %v", got, tt.counts)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment kb.Environment
+		successes   int
+		failures    int
+		want        float64
+	}{
+		{name: "exact", environment: debian12, successes: 1, want: 1 * 2.0 / 3},
+		{name: "other version", environment: kb.Environment{OS: "debian", OSVersion: "11", PackageManager: "apt", Architecture: "amd64"}, successes: 1, want: 0.7 * 2.0 / 3},
+		{name: "other OS", environment: kb.Environment{OS: "fedora", OSVersion: "12", PackageManager: "apt", Architecture: "amd64"}, successes: 1},
+		{name: "mostly failing", environment: debian12, successes: 1, failures: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := kb.Open(t.TempDir())
+			for i := 0; i < tt.successes+tt.failures; i++ {
+				if err := store.Record("fp", "missing_command", "Command not found", "sl", tt.environment, "fix", []byte(`{}`), i < tt.successes); err != nil {
+					t.Fatal(err)
+				}
+			}
+			candidates, err := store.Candidates("fp", debian12)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == 0 {
+				if len(candidates) != 0 {
+					t.Errorf("%d candidates, want none", len(candidates))
+				}
+				return
+			}
+			if len(candidates) != 1 {
+				t.Fatalf("%d candidates, want 1", len(candidates))
+			}
+			if got := candidates[0].Score; got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -18,11 +18,19 @@ const (
 	RiskHigh   RiskLevel = "high"
 )
 
+type SuggestionStep struct {
+	Command      string    `json:"command"`
+	Rollback     string    `json:"rollback,omitempty"`
+	Precondition string    `json:"precondition,omitempty"`
+	RiskLevel    RiskLevel `json:"risk_level,omitempty"`
+}
+
 type Suggestion struct {
-	Explanation string    `json:"explanation"`
-	ProposedFix string    `json:"proposed_fix"`
-	RiskLevel   RiskLevel `json:"risk_level"`
-	FixType     string    `json:"fix_type"`
+	Explanation string           `json:"explanation"`
+	ProposedFix string           `json:"proposed_fix"`
+	RiskLevel   RiskLevel        `json:"risk_level"`
+	FixType     string           `json:"fix_type"`
+	Steps       []SuggestionStep `json:"steps,omitempty"`
 }
 
 type Environment struct {
@@ -71,7 +79,7 @@ func (o *OpenAIClient) GetSuggestion(req *Request) (*Suggestion, error) {
 	body, _ := json.Marshal(map[string]interface{}{
 		"model": o.Model,
 		"messages": []map[string]string{
-			{"role": "system", "content": "You are a DevOps fix assistant. Analyze failed commands. fix_type should be 'replacement' if the command is a typo (return the corrected command), or 'preparation' if installing a missing dependency. Respond ONLY with JSON: {\"explanation\": \"one sentence\", \"proposed_fix\": \"command\", \"risk_level\": \"low\", \"fix_type\": \"replacement\" or \"preparation\"}"},
+			{"role": "system", "content": "You are a DevOps fix assistant. Analyze failed commands. fix_type should be 'replacement' if the command is a typo (return the corrected command), or 'preparation' if installing a missing dependency. If the fix needs several commands, list them in order in steps, each with an optional rollback command that undoes it. Respond ONLY with JSON: {\"explanation\": \"one sentence\", \"proposed_fix\": \"command\", \"risk_level\": \"low\", \"fix_type\": \"replacement\" or \"preparation\", \"steps\": [{\"command\": \"command\", \"rollback\": \"command\"}]}"},
 			{"role": "user", "content": fmt.Sprintf("Failed command: %s\nStderr: %s\nOS: %s, Package Manager: %s\nReturn JSON.", req.Command, req.Stderr, req.Environment.OS, req.Environment.PackageManager)},
 		},
 	})
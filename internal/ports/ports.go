@@ -0,0 +1,47 @@
+package ports
+
+import (
+	"fmt"
+	"net"
+	"os/user"
+	"strconv"
+)
+
+type Process struct {
+	PID     int    `json:"pid"`
+	UID     int    `json:"uid"`
+	User    string `json:"user,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+func (p *Process) String() string {
+	if p.PID == 0 {
+		return fmt.Sprintf("a process owned by %s", p.User)
+	}
+	return fmt.Sprintf("PID %d (%s) owned by %s", p.PID, p.Command, p.User)
+}
+
+func lookupUser(uid int) string {
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(uid)
+}
+
+func Free(after int) (int, error) {
+	for port := after + 1; port <= after+100 && port < 65536; port++ {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		return port, nil
+	}
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
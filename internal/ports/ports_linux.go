@@ -0,0 +1,85 @@
+//go:build linux
+
+package ports
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const tcpListen = "0A"
+
+func Owner(port int) (*Process, error) {
+	inodes := map[string]int{}
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		if err := listeningInodes(table, port, inodes); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if len(inodes) == 0 {
+		return nil, fmt.Errorf("no process is listening on port %d", port)
+	}
+
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		uid, ok := inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")]
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.Split(fd, "/")[2])
+		if err != nil {
+			continue
+		}
+		return &Process{PID: pid, UID: uid, User: lookupUser(uid), Command: cmdline(pid)}, nil
+	}
+
+	// The socket exists but its process is not visible to us, usually
+	// because it belongs to another user.
+	for _, uid := range inodes {
+		return &Process{UID: uid, User: lookupUser(uid)}, nil
+	}
+	return nil, nil
+}
+
+func listeningInodes(table string, port int, inodes map[string]int) error {
+	file, err := os.Open(table)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListen {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		if p, err := strconv.ParseInt(hexPort, 16, 32); err != nil || int(p) != port {
+			continue
+		}
+		uid, _ := strconv.Atoi(fields[7])
+		inodes[fields[9]] = uid
+	}
+	return scanner.Err()
+}
+
+func cmdline(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+}
@@ -0,0 +1,41 @@
+//go:build !linux
+
+package ports
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+func Owner(port int) (*Process, error) {
+	out, err := exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-Fpu").Output()
+	if err != nil || len(out) == 0 {
+		return nil, fmt.Errorf("no process is listening on port %d", port)
+	}
+
+	proc := &Process{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		if line[0] == 'p' {
+			if proc.PID != 0 {
+				break
+			}
+			proc.PID, _ = strconv.Atoi(line[1:])
+		} else if line[0] == 'u' {
+			proc.UID, _ = strconv.Atoi(line[1:])
+		}
+	}
+	if proc.PID == 0 {
+		return nil, fmt.Errorf("no process is listening on port %d", port)
+	}
+
+	proc.User = lookupUser(proc.UID)
+	if cmd, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(proc.PID)).Output(); err == nil {
+		proc.Command = strings.TrimSpace(string(cmd))
+	}
+	return proc, nil
+}
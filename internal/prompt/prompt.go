@@ -0,0 +1,224 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/autofix/cli/internal/llm"
+)
+
+type Kind string
+
+const (
+	KindExecuteFix Kind = "execute_fix"
+	KindApproveFix Kind = "approve_fix"
+	KindSudo       Kind = "sudo"
+	KindRollback   Kind = "rollback"
+	KindSaveEnv    Kind = "save_env"
+	KindUndo       Kind = "undo"
+	KindOfferFix   Kind = "offer_fix"
+)
+
+type Question struct {
+	Kind     Kind          `json:"kind"`
+	Text     string        `json:"text"`
+	Commands []string      `json:"commands,omitempty"`
+	Risk     llm.RiskLevel `json:"risk,omitempty"`
+	Sudo     bool          `json:"sudo,omitempty"`
+	Editable bool          `json:"editable,omitempty"`
+}
+
+type Answer int
+
+const (
+	No Answer = iota
+	Yes
+	Edit
+)
+
+func (a Answer) String() string {
+	switch a {
+	case Yes:
+		return "yes"
+	case Edit:
+		return "edit"
+	default:
+		return "no"
+	}
+}
+
+type Prompter interface {
+	Confirm(q *Question) (Answer, error)
+	Edit(command string) (string, error)
+}
+
+var ErrNoInput = errors.New("no input available to answer the prompt; rerun with --yes or --no-input")
+
+type Terminal struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func NewTerminal(in io.Reader, out io.Writer) *Terminal {
+	return &Terminal{in: bufio.NewReader(in), out: out}
+}
+
+func (t *Terminal) Confirm(q *Question) (Answer, error) {
+	options := "y/N"
+	if q.Editable {
+		options = "y/N/e"
+	}
+	fmt.Fprintf(t.out, "%s (%s): ", q.Text, options)
+
+	line, err := t.readLine()
+	if err != nil {
+		return No, err
+	}
+	switch strings.ToLower(line) {
+	case "y", "yes":
+		return Yes, nil
+	case "e", "edit":
+		if q.Editable {
+			return Edit, nil
+		}
+	}
+	return No, nil
+}
+
+func (t *Terminal) Edit(command string) (string, error) {
+	fmt.Fprintf(t.out, "Command [%s]: ", command)
+	line, err := t.readLine()
+	if err != nil {
+		return "", err
+	}
+	if line == "" {
+		return command, nil
+	}
+	return line, nil
+}
+
+func (t *Terminal) readLine() (string, error) {
+	line, err := t.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		fmt.Fprintln(t.out)
+		return "", ErrNoInput
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+type AlwaysYes struct{}
+
+func (AlwaysYes) Confirm(q *Question) (Answer, error) { return Yes, nil }
+
+func (AlwaysYes) Edit(command string) (string, error) { return command, nil }
+
+type AlwaysNo struct{}
+
+func (AlwaysNo) Confirm(q *Question) (Answer, error) { return No, nil }
+
+func (AlwaysNo) Edit(command string) (string, error) { return command, nil }
+
+// Policy answers yes to fix questions of a known risk up to MaxRisk and
+// defers everything else, including sudo unless AllowSudo is set, to
+// Otherwise. Questions without a risk, such as undo and rollback, always
+// go to Otherwise.
+type Policy struct {
+	MaxRisk   llm.RiskLevel
+	AllowSudo bool
+	Otherwise Prompter
+}
+
+var riskRank = map[llm.RiskLevel]int{
+	llm.RiskLow:    1,
+	llm.RiskMedium: 2,
+	llm.RiskHigh:   3,
+}
+
+func (p *Policy) Confirm(q *Question) (Answer, error) {
+	if p.accepts(q) {
+		return Yes, nil
+	}
+	if p.Otherwise == nil {
+		return No, nil
+	}
+	return p.Otherwise.Confirm(q)
+}
+
+func (p *Policy) accepts(q *Question) bool {
+	switch q.Kind {
+	case KindExecuteFix, KindApproveFix:
+	case KindSudo:
+		if !p.AllowSudo {
+			return false
+		}
+	default:
+		return false
+	}
+	risk, known := riskRank[q.Risk]
+	if !known {
+		risk = riskRank[llm.RiskHigh]
+	}
+	max, ok := riskRank[p.MaxRisk]
+	return ok && (!q.Sudo || p.AllowSudo) && risk <= max
+}
+
+func (p *Policy) Edit(command string) (string, error) {
+	if p.Otherwise == nil {
+		return command, nil
+	}
+	return p.Otherwise.Edit(command)
+}
+
+// Scripted answers from a fixed list, for tests. Each answer is "y", "n",
+// or "e:<command>" to edit the fix into <command>.
+type Scripted struct {
+	Answers []string
+	Asked   []*Question
+
+	edit string
+}
+
+func (s *Scripted) Confirm(q *Question) (Answer, error) {
+	s.Asked = append(s.Asked, q)
+	if len(s.Asked) > len(s.Answers) {
+		return No, fmt.Errorf("no scripted answer for %q", q.Text)
+	}
+
+	answer := s.Answers[len(s.Asked)-1]
+	switch {
+	case answer == "y":
+		return Yes, nil
+	case strings.HasPrefix(answer, "e:"):
+		s.edit = strings.TrimPrefix(answer, "e:")
+		return Edit, nil
+	default:
+		return No, nil
+	}
+}
+
+func (s *Scripted) Edit(command string) (string, error) {
+	if s.edit == "" {
+		return command, nil
+	}
+	edited := s.edit
+	s.edit = ""
+	return edited, nil
+}
+
+var ciVariables = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "JENKINS_URL", "TF_BUILD", "CIRCLECI"}
+
+func IsCI() bool {
+	for _, name := range ciVariables {
+		if value := os.Getenv(name); value != "" && value != "false" && value != "0" {
+			return true
+		}
+	}
+	return false
+}
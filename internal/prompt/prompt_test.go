@@ -0,0 +1,68 @@
+package prompt_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/autofix/cli/internal/llm"
+	"github.com/autofix/cli/internal/prompt"
+)
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy prompt.Policy
+		q      prompt.Question
+		want   prompt.Answer
+	}{
+		{"low fix under medium", prompt.Policy{MaxRisk: llm.RiskMedium}, prompt.Question{Kind: prompt.KindExecuteFix, Risk: llm.RiskLow}, prompt.Yes},
+		{"medium fix under medium", prompt.Policy{MaxRisk: llm.RiskMedium}, prompt.Question{Kind: prompt.KindApproveFix, Risk: llm.RiskMedium}, prompt.Yes},
+		{"high fix under medium", prompt.Policy{MaxRisk: llm.RiskMedium}, prompt.Question{Kind: prompt.KindExecuteFix, Risk: llm.RiskHigh}, prompt.No},
+		{"unknown risk counts as high", prompt.Policy{MaxRisk: llm.RiskMedium}, prompt.Question{Kind: prompt.KindExecuteFix}, prompt.No},
+		{"unknown risk under high", prompt.Policy{MaxRisk: llm.RiskHigh}, prompt.Question{Kind: prompt.KindExecuteFix, Risk: "extreme"}, prompt.Yes},
+		{"sudo fix without allow_sudo", prompt.Policy{MaxRisk: llm.RiskHigh}, prompt.Question{Kind: prompt.KindExecuteFix, Risk: llm.RiskLow, Sudo: true}, prompt.No},
+		{"sudo question without allow_sudo", prompt.Policy{MaxRisk: llm.RiskHigh}, prompt.Question{Kind: prompt.KindSudo, Risk: llm.RiskLow}, prompt.No},
+		{"sudo with allow_sudo", prompt.Policy{MaxRisk: llm.RiskHigh, AllowSudo: true}, prompt.Question{Kind: prompt.KindSudo, Risk: llm.RiskLow, Sudo: true}, prompt.Yes},
+		{"undo is never automatic", prompt.Policy{MaxRisk: llm.RiskHigh, AllowSudo: true}, prompt.Question{Kind: prompt.KindUndo, Risk: llm.RiskLow}, prompt.No},
+		{"save env is never automatic", prompt.Policy{MaxRisk: llm.RiskHigh}, prompt.Question{Kind: prompt.KindSaveEnv}, prompt.No},
+		{"no max risk", prompt.Policy{}, prompt.Question{Kind: prompt.KindExecuteFix, Risk: llm.RiskLow}, prompt.No},
+		{"deferred to otherwise", prompt.Policy{MaxRisk: llm.RiskLow, Otherwise: prompt.AlwaysYes{}}, prompt.Question{Kind: prompt.KindExecuteFix, Risk: llm.RiskHigh}, prompt.Yes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.Confirm(&tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("answer = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	tests := []struct {
+		input    string
+		editable bool
+		want     prompt.Answer
+		err      error
+	}{
+		{input: "y\n", want: prompt.Yes},
+		{input: "YES\n", want: prompt.Yes},
+		{input: "\n", want: prompt.No},
+		{input: "e\n", editable: true, want: prompt.Edit},
+		{input: "e\n", want: prompt.No},
+		{input: "", want: prompt.No, err: prompt.ErrNoInput},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		terminal := prompt.NewTerminal(strings.NewReader(tt.input), &out)
+		got, err := terminal.Confirm(&prompt.Question{Text: "Apply this fix?", Editable: tt.editable})
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("input %q: answer = %s, %v, want %s, %v", tt.input, got, err, tt.want, tt.err)
+		}
+	}
+}
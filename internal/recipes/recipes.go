@@ -0,0 +1,248 @@
+package recipes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/autofix/cli/internal/env"
+	"github.com/autofix/cli/internal/errorparser"
+)
+
+type Match struct {
+	ErrorType string `yaml:"error_type,omitempty"`
+	Stderr    string `yaml:"stderr,omitempty"`
+	Command   string `yaml:"command,omitempty"`
+}
+
+type Constraint struct {
+	OS             []string `yaml:"os,omitempty"`
+	PackageManager []string `yaml:"package_manager,omitempty"`
+	Architecture   []string `yaml:"architecture,omitempty"`
+}
+
+type Step struct {
+	Command      string   `yaml:"command,omitempty"`
+	Env          []string `yaml:"env,omitempty"`
+	RiskLevel    string   `yaml:"risk_level,omitempty"`
+	Precondition string   `yaml:"precondition,omitempty"`
+	Rollback     string   `yaml:"rollback,omitempty"`
+}
+
+type Fix struct {
+	Type        string `yaml:"type,omitempty"`
+	RiskLevel   string `yaml:"risk_level,omitempty"`
+	Explanation string `yaml:"explanation,omitempty"`
+	Steps       []Step `yaml:"steps"`
+}
+
+type Test struct {
+	Command string `yaml:"command,omitempty"`
+	Stderr  string `yaml:"stderr"`
+	NoMatch bool   `yaml:"no_match,omitempty"`
+}
+
+type Recipe struct {
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description,omitempty"`
+	Match       Match      `yaml:"match"`
+	Environment Constraint `yaml:"environment,omitempty"`
+	Fix         Fix        `yaml:"fix"`
+	Tests       []Test     `yaml:"tests,omitempty"`
+
+	Source  string         `yaml:"-"`
+	stderr  *regexp.Regexp `yaml:"-"`
+	command *regexp.Regexp `yaml:"-"`
+}
+
+type Bundle struct {
+	Recipes []*Recipe `yaml:"recipes"`
+}
+
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".autofix", "recipes"), nil
+}
+
+func (r *Recipe) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("recipe without a name")
+	}
+	if r.Match.ErrorType == "" && r.Match.Stderr == "" && r.Match.Command == "" {
+		return fmt.Errorf("recipe %s: match needs error_type, stderr or command", r.Name)
+	}
+	if len(r.Fix.Steps) == 0 {
+		return fmt.Errorf("recipe %s: fix has no steps", r.Name)
+	}
+
+	var err error
+	if r.Match.Stderr != "" {
+		if r.stderr, err = regexp.Compile(r.Match.Stderr); err != nil {
+			return fmt.Errorf("recipe %s: invalid stderr pattern: %w", r.Name, err)
+		}
+	}
+	if r.Match.Command != "" {
+		if r.command, err = regexp.Compile(r.Match.Command); err != nil {
+			return fmt.Errorf("recipe %s: invalid command pattern: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Recipe) Matches(info *errorparser.ErrorInfo, command, stderr string) bool {
+	if r.Match.ErrorType != "" && r.Match.ErrorType != string(info.Type) {
+		return false
+	}
+	if r.stderr != nil && !r.stderr.MatchString(stderr) {
+		return false
+	}
+	if r.command != nil && !r.command.MatchString(command) {
+		return false
+	}
+	return true
+}
+
+func (r *Recipe) Applies(e *env.Environment) bool {
+	return allowed(r.Environment.OS, string(e.OS)) &&
+		allowed(r.Environment.PackageManager, string(e.PackageManager)) &&
+		allowed(r.Environment.Architecture, string(e.Architecture))
+}
+
+func allowed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func Find(list []*Recipe, info *errorparser.ErrorInfo, e *env.Environment, command, stderr string) []*Recipe {
+	matched := []*Recipe{}
+	for _, r := range list {
+		if r.Applies(e) && r.Matches(info, command, stderr) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+func Parse(data []byte, source string) ([]*Recipe, error) {
+	var bundle Bundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	if len(bundle.Recipes) == 0 {
+		var single Recipe
+		if err := yaml.Unmarshal(data, &single); err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		if single.Name != "" {
+			bundle.Recipes = []*Recipe{&single}
+		}
+	}
+
+	for _, r := range bundle.Recipes {
+		r.Source = source
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+	}
+	return bundle.Recipes, nil
+}
+
+func LoadFile(path string) ([]*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, path)
+}
+
+func LoadDir(dir string) ([]*Recipe, error) {
+	all := []*Recipe{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+
+		list, err := LoadFile(path)
+		if err != nil {
+			return err
+		}
+		all = append(all, list...)
+		return nil
+	})
+	return all, err
+}
+
+func LoadAll(paths []string) ([]*Recipe, error) {
+	all := []*Recipe{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var list []*Recipe
+		if info.IsDir() {
+			list, err = LoadDir(path)
+		} else {
+			list, err = LoadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+	}
+	return all, nil
+}
+
+func Marshal(list []*Recipe) ([]byte, error) {
+	return yaml.Marshal(&Bundle{Recipes: list})
+}
+
+type TestResult struct {
+	Recipe *Recipe
+	Test   Test
+	Passed bool
+	Got    bool
+}
+
+func RunTests(list []*Recipe) []TestResult {
+	results := []TestResult{}
+	for _, r := range list {
+		for _, t := range r.Tests {
+			info := errorparser.Parse(t.Stderr, 1)
+			got := r.Matches(info, t.Command, t.Stderr)
+			results = append(results, TestResult{Recipe: r, Test: t, Passed: got != t.NoMatch, Got: got})
+		}
+	}
+	return results
+}
@@ -49,13 +49,10 @@ func NewValidator() *Validator {
 }
 
 func (v *Validator) Validate(cmd string) error {
-	cmdLower := toLower(cmd)
-
-	for blocked := range blockedCmds {
-		if contains(cmdLower, blocked) {
-			return &ValidationError{Reason: "destructive command blocked: " + blocked}
-		}
+	if err := v.CheckDestructive(cmd); err != nil {
+		return err
 	}
+	cmdLower := toLower(cmd)
 
 	for allowed := range allowlistCmds {
 		if hasPrefix(cmdLower, allowed+" ") || hasPrefix(cmdLower, allowed+"\t") {
@@ -70,6 +67,18 @@ func (v *Validator) Validate(cmd string) error {
 	return nil
 }
 
+// CheckDestructive rejects blocklisted commands, which are never run
+// whatever the confirmation settings.
+func (v *Validator) CheckDestructive(cmd string) error {
+	cmdLower := toLower(cmd)
+	for blocked := range blockedCmds {
+		if contains(cmdLower, blocked) {
+			return &ValidationError{Reason: "destructive command blocked: " + blocked}
+		}
+	}
+	return nil
+}
+
 func (v *Validator) IsLowRisk(cmd string) bool {
 	cmdLower := toLower(cmd)
 
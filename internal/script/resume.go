@@ -0,0 +1,109 @@
+package script
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Resume records where a script run stopped, so it can continue from the
+// failing step with the shell state it had.
+type Resume struct {
+	Script    string    `json:"script"`
+	Checksum  string    `json:"checksum"`
+	Step      int       `json:"step"`
+	Line      int       `json:"line"`
+	Text      string    `json:"text"`
+	State     State     `json:"state"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ResumeDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".autofix", "scripts"), nil
+}
+
+// ResumePath is keyed by the script's absolute path.
+func ResumePath(script string) (string, error) {
+	dir, err := ResumeDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(script))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// LoadResume returns the saved position for s, or nil if there is none.
+func LoadResume(s *Script) (*Resume, error) {
+	path, err := ResumePath(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Resume
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Position finds the saved step in s. If the script was edited since, the
+// step is looked up by its text.
+func (r *Resume) Position(s *Script) (int, error) {
+	if r.Checksum == s.Checksum && r.Step < len(s.Steps) {
+		return r.Step, nil
+	}
+	if i := s.Find(r.Text); i >= 0 {
+		return i, nil
+	}
+	return 0, fmt.Errorf("the script changed and the step at line %d (%s) is gone", r.Line, Step{Text: r.Text}.Summary())
+}
+
+func SaveResume(s *Script, step int, state *State) error {
+	path, err := ResumePath(s.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	r := &Resume{
+		Script:    s.Path,
+		Checksum:  s.Checksum,
+		Step:      step,
+		Line:      s.Steps[step].Line,
+		Text:      s.Steps[step].Text,
+		State:     *state,
+		UpdatedAt: time.Now().UTC(),
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func RemoveResume(s *Script) error {
+	path, err := ResumePath(s.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package script
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const defaultInterpreter = "/bin/sh"
+
+var heredocPattern = regexp.MustCompile(`<<(-?)\s*['"]?([A-Za-z_][A-Za-z0-9_]*)['"]?`)
+
+// notHeredoc matches the other uses of <<: here-strings and shifts in
+// arithmetic.
+var notHeredoc = regexp.MustCompile(`<<<+|\$?\(\([^)]*\)\)|\$\[[^]]*\]`)
+
+// Step is one top-level statement of a script.
+type Step struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Summary is the step's first line, for progress output.
+func (s Step) Summary() string {
+	first, _, more := strings.Cut(s.Text, "\n")
+	if more {
+		return first + " ..."
+	}
+	return first
+}
+
+type Script struct {
+	Path        string
+	Interpreter string
+	Checksum    string
+	Steps       []Step
+}
+
+// Load reads a shell script and splits it into top-level statements.
+func Load(path string) (*Script, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	s := &Script{
+		Path:        abs,
+		Interpreter: interpreter(string(content)),
+		Checksum:    hex.EncodeToString(sum[:]),
+	}
+	s.Steps, err = Split(string(content), func(chunk string) bool {
+		return exec.Command(s.Interpreter, "-n", "-c", chunk).Run() == nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// interpreter returns the shell named by the shebang, ignoring its
+// options since every step runs on its own.
+func interpreter(content string) string {
+	line, _, _ := strings.Cut(content, "\n")
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if !strings.HasPrefix(line, "#!") || len(fields) == 0 {
+		return defaultInterpreter
+	}
+	if filepath.Base(fields[0]) == "env" && len(fields) > 1 {
+		return fields[1]
+	}
+	return fields[0]
+}
+
+// Split groups lines into statements: a line starts a new statement only
+// once the previous one is complete, meaning no continuation or here-doc
+// is open and complete reports that it parses.
+func Split(content string, complete func(chunk string) bool) ([]Step, error) {
+	var steps []Step
+	var buf []string
+	start, heredoc, stripTabs := 0, "", false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if len(buf) == 0 {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			start = n
+		}
+		buf = append(buf, line)
+
+		if heredoc != "" {
+			if line == heredoc || (stripTabs && strings.TrimLeft(line, "\t") == heredoc) {
+				heredoc = ""
+			} else {
+				continue
+			}
+		} else if m := heredocPattern.FindStringSubmatch(notHeredoc.ReplaceAllString(line, " ")); m != nil {
+			heredoc, stripTabs = m[2], m[1] == "-"
+			continue
+		}
+		if strings.HasSuffix(line, `\`) {
+			continue
+		}
+
+		chunk := strings.Join(buf, "\n")
+		if complete(chunk) {
+			steps = append(steps, Step{Line: start, Text: chunk})
+			buf = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(buf) > 0 {
+		return nil, fmt.Errorf("line %d: incomplete or invalid statement", start)
+	}
+	return steps, nil
+}
+
+// Find returns the index of the step with the given text, or -1.
+func (s *Script) Find(text string) int {
+	for i, step := range s.Steps {
+		if step.Text == text {
+			return i
+		}
+	}
+	return -1
+}
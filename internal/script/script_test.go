@@ -0,0 +1,74 @@
+package script_test
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/autofix/cli/internal/script"
+)
+
+func parses(chunk string) bool {
+	return exec.Command("bash", "-n", "-c", chunk).Run() == nil
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		steps   []script.Step
+	}{
+		{
+			name:    "simple",
+			content: "#!/bin/sh\n\necho one\n# comment\necho two\n",
+			steps:   []script.Step{{Line: 3, Text: "echo one"}, {Line: 5, Text: "echo two"}},
+		},
+		{
+			name:    "compound",
+			content: "if true; then\n  echo yes\nfi\necho after\n",
+			steps:   []script.Step{{Line: 1, Text: "if true; then\n  echo yes\nfi"}, {Line: 4, Text: "echo after"}},
+		},
+		{
+			name:    "continuation",
+			content: "echo one \\\n  two\necho three\n",
+			steps:   []script.Step{{Line: 1, Text: "echo one \\\n  two"}, {Line: 3, Text: "echo three"}},
+		},
+		{
+			name:    "heredoc",
+			content: "cat <<EOF\nif\nEOF\necho after\n",
+			steps:   []script.Step{{Line: 1, Text: "cat <<EOF\nif\nEOF"}, {Line: 4, Text: "echo after"}},
+		},
+		{
+			name:    "heredoc stripping tabs",
+			content: "cat <<-'EOF'\n\tfi\n\tEOF\necho after\n",
+			steps:   []script.Step{{Line: 1, Text: "cat <<-'EOF'\n\tfi\n\tEOF"}, {Line: 4, Text: "echo after"}},
+		},
+		{
+			name:    "here-string",
+			content: "grep x <<< hello\necho after\n",
+			steps:   []script.Step{{Line: 1, Text: "grep x <<< hello"}, {Line: 2, Text: "echo after"}},
+		},
+		{
+			name:    "shift",
+			content: "echo $((1<<n))\necho after\n",
+			steps:   []script.Step{{Line: 1, Text: "echo $((1<<n))"}, {Line: 2, Text: "echo after"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := script.Split(tt.content, parses)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(steps, tt.steps) {
+				t.Errorf("steps = %q, want %q", steps, tt.steps)
+			}
+		})
+	}
+}
+
+func TestSplitIncomplete(t *testing.T) {
+	if _, err := script.Split("echo one\nif true; then\n  echo two\n", parses); err == nil {
+		t.Error("unterminated if split without error")
+	}
+}
@@ -0,0 +1,234 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/autofix/cli/internal/executor"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// functionPattern matches a step that is a function definition.
+var functionPattern = regexp.MustCompile(`^(?:function\s+)?[A-Za-z_][A-Za-z0-9_.:-]*\s*\(\s*\)[\s\S]*\}$`)
+
+// shellOwned are variables the shell maintains itself.
+var shellOwned = map[string]bool{
+	"_": true, "PWD": true, "OLDPWD": true, "SHLVL": true, "PPID": true, "LINENO": true,
+	"RANDOM": true, "SRANDOM": true, "SECONDS": true, "HISTCMD": true, "BASHPID": true,
+	"EPOCHSECONDS": true, "EPOCHREALTIME": true, "PIPESTATUS": true, "FUNCNAME": true,
+	"BASH_COMMAND": true, "BASH_LINENO": true, "BASH_SOURCE": true, "BASH_ARGC": true, "BASH_ARGV": true,
+}
+
+// restorable are the shell options carried between steps.
+var restorable = map[string]bool{
+	"errexit": true, "nounset": true, "pipefail": true, "noglob": true, "noclobber": true,
+	"allexport": true, "xtrace": true, "verbose": true, "errtrace": true, "functrace": true,
+}
+
+// State is the shell state carried from one step to the next.
+type State struct {
+	Dir string   `json:"dir"`
+	Env []string `json:"env,omitempty"`
+	// Vars are the unexported variables a step set, as the interpreter's
+	// set builtin prints them.
+	Vars []string `json:"vars,omitempty"`
+	// Functions are the defined functions, from typeset -f or, for shells
+	// that cannot list them such as dash, the steps that defined them.
+	Functions string   `json:"functions,omitempty"`
+	Options   []string `json:"options,omitempty"`
+}
+
+// restore is shell code that brings back the variables, functions and
+// options; options come last so that set -e or -u cannot trip it.
+func (s *State) restore() string {
+	var b strings.Builder
+	for _, v := range s.Vars {
+		b.WriteString(v + "\n")
+	}
+	b.WriteString(s.Functions)
+	for _, option := range s.Options {
+		b.WriteString("set -o " + option + "\n")
+	}
+	return b.String()
+}
+
+// Shell is an executor that runs every request in the script's current
+// State. The current Step runs under the script's interpreter and, if it
+// succeeds, its final working directory, variables, functions and options
+// become the new State; anything else, such as a fix, runs as usual in
+// that state.
+type Shell struct {
+	Executor    executor.Executor
+	Interpreter string
+	State       *State
+	Step        string
+
+	dir string
+}
+
+func NewShell(ex executor.Executor, interpreter string, state *State) (*Shell, error) {
+	dir, err := os.MkdirTemp("", "autofix-script-")
+	if err != nil {
+		return nil, err
+	}
+	return &Shell{Executor: ex, Interpreter: interpreter, State: state, dir: dir}, nil
+}
+
+func (s *Shell) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+func (s *Shell) Unwrap() executor.Executor {
+	return s.Executor
+}
+
+func (s *Shell) Run(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+	run := *req
+	run.Dir, run.Env = s.State.Dir, append(append([]string{}, s.State.Env...), req.Env...)
+	if req.Command != s.Step {
+		return s.Executor.Run(ctx, &run)
+	}
+
+	stepFile := filepath.Join(s.dir, "step")
+	for _, name := range dumps {
+		os.Remove(stepFile + "." + name)
+	}
+	if err := os.WriteFile(stepFile, []byte(s.wrap(stepFile)), 0600); err != nil {
+		return nil, err
+	}
+
+	run.Command = s.Interpreter + " " + stepFile
+	result, err := s.Executor.Run(ctx, &run)
+	if err != nil {
+		return result, err
+	}
+	result.Command = s.Step
+	// A failed step is retried, or resumed, from the state before it, not
+	// from wherever it stopped partway.
+	if result.Success {
+		s.update(stepFile)
+	}
+	return result, nil
+}
+
+var dumps = []string{"base", "cwd", "env", "vars", "functions", "options"}
+
+// wrap surrounds the step with code that restores the state before it
+// and dumps the new state after it. Variables are dumped before the
+// restore too, so that only the ones the script set are kept; bash lists
+// them without functions in posix mode.
+func (s *Shell) wrap(stepFile string) string {
+	dump := func(name string) string { return quote(stepFile + "." + name) }
+	setVars := "( (set -o posix) 2>/dev/null && set -o posix; set ) >%s 2>/dev/null\n"
+	var b strings.Builder
+	fmt.Fprintf(&b, setVars, dump("base"))
+	b.WriteString(s.State.restore())
+	b.WriteString(s.Step + "\n")
+	fmt.Fprintf(&b, "__autofix_status=$?\n{ set +o >%s; set +euxv; } 2>/dev/null\n", dump("options"))
+	fmt.Fprintf(&b, "pwd >%s\nenv >%s\n", dump("cwd"), dump("env"))
+	fmt.Fprintf(&b, setVars, dump("vars"))
+	fmt.Fprintf(&b, "command -v typeset >/dev/null 2>&1 && typeset -f >%s\n", dump("functions"))
+	b.WriteString("exit $__autofix_status\n")
+	return b.String()
+}
+
+// update reads the state a successful step left behind. A step that exits
+// the shell itself leaves none, and the previous state stays.
+func (s *Shell) update(stepFile string) {
+	read := func(name string) (string, bool) {
+		content, err := os.ReadFile(stepFile + "." + name)
+		return string(content), err == nil
+	}
+	cwd, ok := read("cwd")
+	if !ok {
+		return
+	}
+	if dir := strings.TrimSpace(cwd); dir != "" {
+		s.State.Dir = dir
+	}
+
+	content, _ := read("env")
+	env := parseEnv(content)
+	s.State.Env = changedEnv(env, os.Environ())
+	exported := map[string]bool{}
+	for _, v := range env {
+		name, _, _ := strings.Cut(v, "=")
+		exported[name] = true
+	}
+	base, _ := read("base")
+	vars, _ := read("vars")
+	s.State.Vars = changedVars(parseEnv(vars), parseEnv(base), exported)
+
+	if functions, ok := read("functions"); ok {
+		s.State.Functions = functions
+	} else if functionPattern.MatchString(strings.TrimSpace(s.Step)) {
+		s.State.Functions += strings.TrimSpace(s.Step) + "\n"
+	}
+
+	options, _ := read("options")
+	s.State.Options = nil
+	for _, line := range strings.Split(options, "\n") {
+		if option, ok := strings.CutPrefix(strings.TrimSpace(line), "set -o "); ok && restorable[option] {
+			s.State.Options = append(s.State.Options, option)
+		}
+	}
+}
+
+// parseEnv parses env(1) output, joining values that span lines.
+func parseEnv(content string) []string {
+	var vars []string
+	if content == "" {
+		return nil
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		if envNamePattern.MatchString(line) || len(vars) == 0 {
+			vars = append(vars, line)
+			continue
+		}
+		vars[len(vars)-1] += "\n" + line
+	}
+	return vars
+}
+
+// changedEnv returns the variables in vars that base lacks or has with a
+// different value.
+func changedEnv(vars, base []string) []string {
+	known := make(map[string]bool, len(base))
+	for _, v := range base {
+		known[v] = true
+	}
+	changed := []string{}
+	for _, v := range vars {
+		name, _, _ := strings.Cut(v, "=")
+		if !known[v] && !shellOwned[name] {
+			changed = append(changed, v)
+		}
+	}
+	return changed
+}
+
+// changedVars returns the variables in vars that are not exported, not
+// maintained by the shell and not already in base unchanged.
+func changedVars(vars, base []string, exported map[string]bool) []string {
+	unchanged := make(map[string]bool, len(base))
+	for _, v := range base {
+		unchanged[v] = true
+	}
+	changed := []string{}
+	for _, v := range vars {
+		name, _, _ := strings.Cut(v, "=")
+		if !unchanged[v] && !exported[name] && !shellOwned[name] && !strings.HasPrefix(name, "__autofix_") {
+			changed = append(changed, v)
+		}
+	}
+	return changed
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
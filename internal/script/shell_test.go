@@ -0,0 +1,89 @@
+package script_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/script"
+)
+
+// run runs step in shell and returns the result.
+func run(t *testing.T, shell *script.Shell, step string) *executor.Result {
+	t.Helper()
+	shell.Step = step
+	result, err := shell.Run(context.Background(), &executor.Request{Command: step})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func newShell(t *testing.T, dir string) *script.Shell {
+	t.Helper()
+	shell, err := script.NewShell(executor.NewLocal(), "/bin/sh", &script.State{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { shell.Close() })
+	return shell
+}
+
+func TestShellState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	shell := newShell(t, dir)
+
+	steps := []struct {
+		step   string
+		ok     bool
+		dir    string
+		stdout string
+	}{
+		{step: "cd sub", ok: true, dir: "sub"},
+		{step: "NAME=first", ok: true, dir: "sub"},
+		{step: "greet() { echo hello $NAME; }", ok: true, dir: "sub"},
+		{step: "greet", ok: true, dir: "sub", stdout: "hello first"},
+		{step: "cd .. && NAME=second && false", ok: false, dir: "sub"},
+		{step: "greet && pwd", ok: true, dir: "sub", stdout: "hello first\n" + filepath.Join(dir, "sub")},
+	}
+	for _, tt := range steps {
+		result := run(t, shell, tt.step)
+		if result.Success != tt.ok {
+			t.Fatalf("%q: success = %v, want %v (stderr %q)", tt.step, result.Success, tt.ok, result.Stderr)
+		}
+		if want := filepath.Join(dir, tt.dir); shell.State.Dir != want {
+			t.Errorf("%q: dir = %s, want %s", tt.step, shell.State.Dir, want)
+		}
+		if tt.stdout != "" && strings.TrimSpace(result.Stdout) != tt.stdout {
+			t.Errorf("%q: stdout = %q, want %q", tt.step, result.Stdout, tt.stdout)
+		}
+	}
+}
+
+func TestShellRetriesFailedStepFromPreviousState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	shell := newShell(t, dir)
+
+	const step = "cd sub && false"
+	for attempt := 1; attempt <= 2; attempt++ {
+		result := run(t, shell, step)
+		if result.Success {
+			t.Fatalf("attempt %d succeeded", attempt)
+		}
+		if result.Stderr != "" {
+			t.Errorf("attempt %d: stderr = %q, want none", attempt, result.Stderr)
+		}
+		if shell.State.Dir != dir {
+			t.Errorf("attempt %d: dir = %s, want %s", attempt, shell.State.Dir, dir)
+		}
+	}
+}
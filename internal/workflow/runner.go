@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/autofix/cli/internal/executor"
+)
+
+// Runner is an executor that runs a step's command with Shell (/bin/sh if
+// empty), so that quoting, redirections, pipes and && work, and limits it
+// to the step's timeout. Fixes and other commands run as usual and without
+// a limit. The script is written locally, so Executor must be local too.
+type Runner struct {
+	Executor executor.Executor
+	Command  string
+	Shell    string
+	Limit    time.Duration
+}
+
+func (r *Runner) Unwrap() executor.Executor {
+	return r.Executor
+}
+
+func (r *Runner) Run(ctx context.Context, req *executor.Request) (*executor.Result, error) {
+	if req.Command != r.Command {
+		return r.Executor.Run(ctx, req)
+	}
+
+	file, err := os.CreateTemp("", "autofix-step-*.sh")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(r.Command + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Limit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Limit)
+		defer cancel()
+	}
+	shell := r.Shell
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	run := *req
+	run.Command = shell + " " + file.Name()
+	result, err := r.Executor.Run(ctx, &run)
+	if err != nil {
+		return result, err
+	}
+	result.Command = r.Command
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Success = false
+		result.Stderr += fmt.Sprintf("autofix: timed out after %s\n", r.Limit)
+	}
+	return result, nil
+}
@@ -0,0 +1,257 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/autofix/cli/internal/config"
+	"github.com/autofix/cli/internal/ensure"
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/fixengine"
+)
+
+const FileName = "autofix.yaml"
+
+var fixTypes = []string{fixengine.FixTypeReplacement, fixengine.FixTypePreparation, fixengine.FixTypeEnvironment}
+
+// Check decides whether a step succeeded. Every condition given must
+// hold; with none, the step succeeds on exit code 0.
+type Check struct {
+	ExitCodes  []int  `yaml:"exit_codes,omitempty"`
+	Output     string `yaml:"output,omitempty"`
+	FileExists string `yaml:"file_exists,omitempty"`
+
+	output *regexp.Regexp
+}
+
+type Step struct {
+	Name    string             `yaml:"name"`
+	Command string             `yaml:"command"`
+	Dir     string             `yaml:"dir,omitempty"`
+	Env     map[string]string  `yaml:"env,omitempty"`
+	Timeout time.Duration      `yaml:"timeout,omitempty"`
+	Retry   config.RetryPolicy `yaml:"retry,omitempty"`
+	Fixes   []string           `yaml:"fixes,omitempty"`
+	Needs   []string           `yaml:"needs,omitempty"`
+	Success Check              `yaml:"success,omitempty"`
+}
+
+type Workflow struct {
+	Requires []*ensure.Requirement `yaml:"requires,omitempty"`
+	Env      map[string]string     `yaml:"env,omitempty"`
+	Steps    []*Step               `yaml:"steps"`
+
+	// Dir is the directory holding the file; step directories are
+	// relative to it.
+	Dir string `yaml:"-"`
+}
+
+// Find looks for autofix.yaml in dir and its parents.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s found in this directory or its parents", FileName)
+		}
+		dir = parent
+	}
+}
+
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := Parse(data, filepath.Dir(abs))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+func Parse(data []byte, dir string) (*Workflow, error) {
+	w := &Workflow{Dir: dir}
+	if err := yaml.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+	if len(w.Steps) == 0 && len(w.Requires) == 0 {
+		return nil, fmt.Errorf("no steps or requirements defined")
+	}
+
+	names := map[string]bool{}
+	for i, s := range w.Steps {
+		if s.Name == "" {
+			return nil, fmt.Errorf("step %d has no name", i+1)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("step %s is defined twice", s.Name)
+		}
+		names[s.Name] = true
+		if s.Command == "" {
+			return nil, fmt.Errorf("step %s has no command", s.Name)
+		}
+		for _, t := range s.Fixes {
+			if !contains(fixTypes, t) {
+				return nil, fmt.Errorf("step %s: unknown fix category %q (want %s)", s.Name, t, strings.Join(fixTypes, ", "))
+			}
+		}
+		if s.Success.Output != "" {
+			var err error
+			if s.Success.output, err = regexp.Compile(s.Success.Output); err != nil {
+				return nil, fmt.Errorf("step %s: invalid output pattern: %w", s.Name, err)
+			}
+		}
+	}
+	for _, s := range w.Steps {
+		for _, need := range s.Needs {
+			if !names[need] {
+				return nil, fmt.Errorf("step %s needs unknown step %s", s.Name, need)
+			}
+		}
+	}
+	if _, err := w.Plan(nil); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Workflow) Step(name string) *Step {
+	for _, s := range w.Steps {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Plan returns the targets and everything they need, each step after its
+// dependencies and otherwise in file order. No targets means every step.
+func (w *Workflow) Plan(targets []string) ([]*Step, error) {
+	if len(targets) == 0 {
+		for _, s := range w.Steps {
+			targets = append(targets, s.Name)
+		}
+	}
+
+	var plan []*Step
+	state := map[string]int{} // 1 while visiting, 2 once planned
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		s := w.Step(name)
+		if s == nil {
+			return fmt.Errorf("unknown step %s", name)
+		}
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, need := range s.Needs {
+			if err := visit(need, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		plan = append(plan, s)
+		return nil
+	}
+	for _, name := range targets {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// WorkDir is the step's directory, resolved against the workflow's.
+func (w *Workflow) WorkDir(s *Step) string {
+	if filepath.IsAbs(s.Dir) {
+		return s.Dir
+	}
+	return filepath.Join(w.Dir, s.Dir)
+}
+
+// Environ returns the workflow's variables overlaid with the step's, as
+// sorted KEY=VAL pairs.
+func (w *Workflow) Environ(s *Step) []string {
+	merged := map[string]string{}
+	for k, v := range w.Env {
+		merged[k] = v
+	}
+	for k, v := range s.Env {
+		merged[k] = v
+	}
+	vars := make([]string, 0, len(merged))
+	for k, v := range merged {
+		vars = append(vars, k+"="+v)
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// Verify reports why result does not meet the check, or nil if it does.
+func (c *Check) Verify(result *executor.Result, dir string) error {
+	if len(c.ExitCodes) > 0 {
+		if !containsInt(c.ExitCodes, result.ExitCode) {
+			return fmt.Errorf("autofix: success check failed: exit code %d is not one of %v", result.ExitCode, c.ExitCodes)
+		}
+	} else if result.ExitCode != 0 {
+		return fmt.Errorf("autofix: success check failed: exit code %d", result.ExitCode)
+	}
+	if c.output != nil && !c.output.MatchString(result.Stdout+result.Stderr) {
+		return fmt.Errorf("autofix: success check failed: output does not match %q", c.Output)
+	}
+	if c.FileExists != "" {
+		path := c.FileExists
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("autofix: success check failed: %s does not exist", path)
+		}
+	}
+	return nil
+}
+
+func (c *Check) IsZero() bool {
+	return len(c.ExitCodes) == 0 && c.Output == "" && c.FileExists == ""
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
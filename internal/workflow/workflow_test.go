@@ -0,0 +1,140 @@
+package workflow_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/autofix/cli/internal/executor"
+	"github.com/autofix/cli/internal/workflow"
+)
+
+const plan = `
+steps:
+  - name: deps
+    command: npm ci
+  - name: lint
+    command: npm run lint
+    needs: [deps]
+  - name: build
+    command: npm run build
+    needs: [deps]
+  - name: test
+    command: npm test
+    needs: [build]
+`
+
+func names(steps []*workflow.Step) string {
+	var list []string
+	for _, s := range steps {
+		list = append(list, s.Name)
+	}
+	return strings.Join(list, " ")
+}
+
+func TestPlan(t *testing.T) {
+	w, err := workflow.Parse([]byte(plan), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		targets []string
+		want    string
+		err     string
+	}{
+		{targets: nil, want: "deps lint build test"},
+		{targets: []string{"test"}, want: "deps build test"},
+		{targets: []string{"lint", "test"}, want: "deps lint build test"},
+		{targets: []string{"test", "deps"}, want: "deps build test"},
+		{targets: []string{"deploy"}, err: "unknown step deploy"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.targets, ","), func(t *testing.T) {
+			steps, err := w.Plan(tt.targets)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(steps); got != tt.want {
+				t.Errorf("Plan(%q) = %s, want %s", tt.targets, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"empty", "env: {A: b}\n", "no steps or requirements"},
+		{"no name", "steps: [{command: make}]\n", "step 1 has no name"},
+		{"duplicate", "steps: [{name: a, command: x}, {name: a, command: y}]\n", "defined twice"},
+		{"no command", "steps: [{name: a}]\n", "step a has no command"},
+		{"unknown fix", "steps: [{name: a, command: x, fixes: [magic]}]\n", `unknown fix category "magic"`},
+		{"bad pattern", "steps: [{name: a, command: x, success: {output: '('}}]\n", "invalid output pattern"},
+		{"unknown need", "steps: [{name: a, command: x, needs: [b]}]\n", "needs unknown step b"},
+		{"cycle", "steps: [{name: a, command: x, needs: [b]}, {name: b, command: y, needs: [a]}]\n", "dependency cycle: a -> b -> a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := workflow.Parse([]byte(tt.yaml), t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		success string
+		result  executor.Result
+		err     string
+	}{
+		{name: "default success", result: executor.Result{}},
+		{name: "default failure", result: executor.Result{ExitCode: 2}, err: "exit code 2"},
+		{name: "allowed exit code", success: "{exit_codes: [0, 1]}", result: executor.Result{ExitCode: 1}},
+		{name: "other exit code", success: "{exit_codes: [0, 1]}", result: executor.Result{ExitCode: 2}, err: "not one of [0 1]"},
+		{name: "output on stderr", success: "{output: 'listening on :\\d+'}", result: executor.Result{Stderr: "listening on :8080\n"}},
+		{name: "output missing", success: "{output: 'listening'}", result: executor.Result{Stdout: "crashed\n"}, err: "output does not match"},
+		{name: "file exists", success: "{file_exists: app}", result: executor.Result{}},
+		{name: "file missing", success: "{file_exists: dist/app}", result: executor.Result{}, err: "does not exist"},
+		{name: "every condition", success: "{output: ok, file_exists: app}", result: executor.Result{ExitCode: 3, Stdout: "ok"}, err: "exit code 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yaml := "steps: [{name: a, command: x}]\n"
+			if tt.success != "" {
+				yaml = "steps: [{name: a, command: x, success: " + tt.success + "}]\n"
+			}
+			w, err := workflow.Parse([]byte(yaml), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := w.Step("a")
+			err = s.Success.Verify(&tt.result, w.WorkDir(s))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("Verify = %v, want success", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Verify = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}